@@ -0,0 +1,150 @@
+package logfmt
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Has returns true if the Record has an attribute 'key', with or without a value.
+func (rec Record) Has(key string) bool {
+	_, exists := rec[key]
+	return exists
+}
+
+// IsFlag returns true if 'key' is a key only attribute, see K.
+func (rec Record) IsFlag(key string) bool {
+	val, exists := rec[key]
+	return exists && val == nil
+}
+
+// Str returns the value of 'key', unquoted. It returns false if there is no
+// such attribute, or if it has no value.
+func (rec Record) Str(key string) (string, bool) {
+	val := rec[key]
+	if val == nil {
+		return "", false
+	}
+	return unquote(*val), true
+}
+
+// Int returns the integer value of 'key', it returns false if there is no such attribute, or if it is not an integer.
+func (rec Record) Int(key string) (int, bool) {
+	s, ok := rec.Str(key)
+	if !ok {
+		return 0, false
+	}
+	x, err := strconv.Atoi(s)
+	return x, err == nil
+}
+
+// Float returns the float value of 'key', it returns false if there is no such attribute, or if it is not a float.
+func (rec Record) Float(key string) (float64, bool) {
+	s, ok := rec.Str(key)
+	if !ok {
+		return 0, false
+	}
+	x, err := strconv.ParseFloat(s, 64)
+	return x, err == nil
+}
+
+// Bool returns the boolean value of 'key', true for a key only attribute. It
+// returns false if there is no such attribute, or if it is not a boolean.
+func (rec Record) Bool(key string) (val bool, ok bool) {
+	if rec.IsFlag(key) {
+		return true, true
+	}
+	s, ok := rec.Str(key)
+	if !ok {
+		return false, false
+	}
+	x, err := strconv.ParseBool(s)
+	return x, err == nil
+}
+
+// DurationOf returns the duration value of 'key', it returns false if there is no such attribute, or if it is not a duration.
+//
+// It is the getter of the Duration builder.
+func (rec Record) DurationOf(key string) (time.Duration, bool) {
+	s, ok := rec.Str(key)
+	if !ok {
+		return 0, false
+	}
+	x, err := time.ParseDuration(s)
+	return x, err == nil
+}
+
+// TimeOf returns the time value of 'key', in TimeFormat or RFC 3339. It
+// returns false if there is no such attribute, or if it is not a time.
+//
+// It is the getter of the Time builder.
+func (rec Record) TimeOf(key string) (time.Time, bool) {
+	s, ok := rec.Str(key)
+	if !ok {
+		return time.Time{}, false
+	}
+	x, err := parseTime(s)
+	return x, err == nil
+}
+
+// parseTime parses a time in TimeFormat, or else in RFC 3339.
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(TimeFormat, s)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339Nano, s)
+	}
+	return t, err
+}
+
+// Clone returns a copy of the Record.
+func (rec Record) Clone() Record {
+	c := make(Record, len(rec))
+	for k, v := range rec {
+		if v != nil { // copy the value too, it might be changed later on
+			x := *v
+			v = &x
+		}
+		c[k] = v
+	}
+	return c
+}
+
+// Merge inserts all the attributes of 'other', they override the existing ones.
+func (rec *Record) Merge(other Record) *Record {
+	for k, v := range other {
+		(*rec)[k] = v
+	}
+	return rec
+}
+
+// Delete removes the attributes 'keys'.
+func (rec *Record) Delete(keys ...string) *Record {
+	for _, k := range keys {
+		delete(*rec, k)
+	}
+	return rec
+}
+
+// Keys returns the Record keys, in Significance order.
+func (rec Record) Keys() []string {
+	keys := make([]string, 0, len(rec))
+	for k := range rec {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return Significance(keys[i], keys[j]) })
+	return keys
+}
+
+// Equal returns true if both Records have the same attributes, written the same way.
+func (rec Record) Equal(other Record) bool {
+	if len(rec) != len(other) {
+		return false
+	}
+	for k, va := range rec {
+		vb, exists := other[k]
+		if !exists || (va == nil) != (vb == nil) || (va != nil && *va != *vb) {
+			return false
+		}
+	}
+	return true
+}
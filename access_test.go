@@ -0,0 +1,76 @@
+package logfmt
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ExampleRecord_Int() {
+	rec := *D("status", 404).Q("path", "/index.html").K("debug")
+	if status, ok := rec.Int("status"); ok && status >= 400 {
+		path, _ := rec.Str("path")
+		fmt.Println("error", status, path, rec.IsFlag("debug"))
+	}
+	//Output: error 404 /index.html true
+}
+
+func TestGetters(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	rec := *D("n", 12).G("load", 0.5).T("ok", false).K("debug").Q("q", "12").
+		Duration("elapsed", time.Second).Time("start", start).S("word", "x")
+
+	if n, ok := rec.Int("n"); !ok || n != 12 {
+		t.Errorf("Int(n) = %v, %v", n, ok)
+	}
+	if n, ok := rec.Int("q"); !ok || n != 12 {
+		t.Errorf("Int(q) = %v, %v: quoted values are unquoted", n, ok)
+	}
+	if _, ok := rec.Int("word"); ok {
+		t.Errorf("Int(word) must fail")
+	}
+	if x, ok := rec.Float("load"); !ok || x != 0.5 {
+		t.Errorf("Float(load) = %v, %v", x, ok)
+	}
+	if b, ok := rec.Bool("ok"); !ok || b {
+		t.Errorf("Bool(ok) = %v, %v", b, ok)
+	}
+	if b, ok := rec.Bool("debug"); !ok || !b {
+		t.Errorf("Bool(debug) = %v, %v", b, ok)
+	}
+	if _, ok := rec.Str("debug"); ok {
+		t.Errorf("Str(debug) must fail on a key only attribute")
+	}
+	if d, ok := rec.DurationOf("elapsed"); !ok || d != time.Second {
+		t.Errorf("DurationOf(elapsed) = %v, %v", d, ok)
+	}
+	if x, ok := rec.TimeOf("start"); !ok || !x.Equal(start) {
+		t.Errorf("TimeOf(start) = %v, %v", x, ok)
+	}
+	if !rec.Has("debug") || rec.Has("missing") || rec.IsFlag("n") {
+		t.Errorf("Has/IsFlag failed")
+	}
+}
+
+func TestRecordManipulation(t *testing.T) {
+	rec := *D("a", 1).K("b").S("cc", "x")
+	c := rec.Clone()
+	if !c.Equal(rec) {
+		t.Errorf("clone %v differs from %v", c, rec)
+	}
+	*c["a"] = "2"
+	if v, _ := rec.Int("a"); v != 1 {
+		t.Errorf("changing the clone changed the record")
+	}
+	c.Delete("b").Merge(*D("a", 3).K("d"))
+	if got, want := c.String(), "a=3 d cc=x"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if got, want := c.Keys(), []string{"a", "d", "cc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v want %v", got, want)
+	}
+	if c.Equal(rec) || (*S("k", "v")).Equal(*Q("k", "v")) {
+		t.Errorf("Equal must compare values the way they are written")
+	}
+}
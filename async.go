@@ -0,0 +1,200 @@
+package logfmt
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Overflow is the policy of an asynchronous Logger when its queue is full.
+type Overflow int
+
+// Overflow policies
+const (
+	OverflowBlock      Overflow = iota // wait for room in the queue
+	OverflowDropNewest                 // drop the record being logged
+	OverflowDropOldest                 // drop the oldest record in the queue, to make room
+)
+
+// DroppedKey is the key of the record reporting the number of records dropped
+// by an asynchronous Logger, like:
+//
+//    level=warn dropped=12
+const DroppedKey = "dropped"
+
+// NewAsync creates a new asynchronous Logger.
+//
+// Records are formatted in the caller's goroutine, and queued. A background
+// goroutine writes them to 'out', and flushes them in batches: whenever the
+// queue is empty.
+//
+// The queue holds up to 'size' records, when it is full 'overflow' decides
+// what to do. Dropped records are reported by a record at warn level, with
+// the number of dropped records in the DroppedKey attribute.
+//
+// Call Flush to wait for the queued records to be written, and Close to stop
+// the background goroutine, on shutdown.
+func NewAsync(out io.Writer, size int, overflow Overflow) *Logger {
+	l := New(out)
+	l.async = &queue{
+		sink:     l.sink,
+		lines:    make(chan *bytes.Buffer, size),
+		flushes:  make(chan chan struct{}),
+		done:     make(chan struct{}),
+		overflow: overflow,
+	}
+	go l.async.run(l.out)
+	return l
+}
+
+// queue of formatted lines, written by a single goroutine
+type queue struct {
+	sink     *sink // where write errors are reported
+	lines    chan *bytes.Buffer
+	flushes  chan chan struct{} // flush requests, closed once done
+	done     chan struct{}      // closed when the goroutine returns
+	overflow Overflow
+	dropped  int64 // the number of lines dropped since the last report, accessed atomically
+
+	lock   sync.RWMutex // guards closed: lines must not be pushed once closed
+	closed bool
+}
+
+// a pool of line buffers, lines are formatted into them and sent to the queue
+var lines = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getLine returns an empty line buffer from the pool
+func getLine() *bytes.Buffer { return lines.Get().(*bytes.Buffer) }
+
+// putLine returns the line buffer to the pool
+func putLine(line *bytes.Buffer) {
+	line.Reset()
+	lines.Put(line)
+}
+
+// push a line in the queue, according to the overflow policy
+func (q *queue) push(line *bytes.Buffer) {
+	q.lock.RLock()
+	if q.closed {
+		q.lock.RUnlock()
+		putLine(line)
+		return
+	}
+	switch q.overflow {
+	case OverflowDropNewest:
+		select {
+		case q.lines <- line:
+		default:
+			atomic.AddInt64(&q.dropped, 1)
+			putLine(line)
+		}
+
+	case OverflowDropOldest:
+		for sent := false; !sent; {
+			select {
+			case q.lines <- line:
+				sent = true
+			default:
+				// make room, unless the writer just did
+				select {
+				case old := <-q.lines:
+					atomic.AddInt64(&q.dropped, 1)
+					putLine(old)
+				default:
+				}
+			}
+		}
+
+	default:
+		q.lines <- line
+	}
+	q.lock.RUnlock()
+}
+
+// flush waits for the queued lines to be written
+func (q *queue) flush() {
+	q.lock.RLock()
+	if q.closed {
+		q.lock.RUnlock()
+		<-q.done
+		return
+	}
+	ack := make(chan struct{})
+	q.flushes <- ack
+	q.lock.RUnlock()
+	<-ack
+}
+
+// close the queue, and wait for the queued lines to be written
+func (q *queue) close() {
+	q.lock.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.lines)
+	}
+	q.lock.Unlock()
+	<-q.done
+}
+
+// run writes the queued lines to 'out', until the queue is closed
+func (q *queue) run(out *bufio.Writer) {
+	defer close(q.done)
+	for {
+		select {
+		case line, ok := <-q.lines:
+			if !ok {
+				q.drain(out)
+				return
+			}
+			q.write(out, line)
+			q.drain(out)
+
+		case ack := <-q.flushes:
+			q.drain(out)
+			close(ack)
+		}
+	}
+}
+
+// drain writes the queued lines, and flushes them all at once
+func (q *queue) drain(out *bufio.Writer) {
+	for more := true; more; {
+		select {
+		case line, ok := <-q.lines:
+			if ok {
+				q.write(out, line)
+			}
+			more = ok
+		default:
+			more = false
+		}
+	}
+	if n := atomic.SwapInt64(&q.dropped, 0); n > 0 {
+		rec := *D(DroppedKey, int(n))
+		rec[LevelKey] = LevelWarn.value()
+		sorter := getSorter()
+		logTo(out, rec, sorter)
+		putSorter(sorter)
+		out.WriteRune('\n')
+	}
+	q.check(out, out.Flush())
+}
+
+// write a line to 'out'
+func (q *queue) write(out *bufio.Writer, line *bytes.Buffer) {
+	_, err := out.Write(line.Bytes())
+	q.check(out, err)
+	putLine(line)
+}
+
+// check reports a write error, and clears it from 'out' to write the next lines
+func (q *queue) check(out *bufio.Writer, err error) {
+	if err != nil {
+		q.sink.fail(err)
+		out.Reset(q.sink.w)
+	}
+}
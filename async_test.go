@@ -0,0 +1,111 @@
+package logfmt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func ExampleNewAsync() {
+	logger := NewAsync(os.Stdout, 128, OverflowBlock)
+	defer logger.Close() // write the queued records on shutdown
+
+	logger.Log(*D("count", 1))
+	logger.Info(*S("user", "bob"))
+	logger.Flush()
+	fmt.Println("flushed")
+
+	//Output:
+	// count=1
+	// user=bob level=info
+	// flushed
+}
+
+// gate is a writer that blocks until it is opened, it tells when it has been entered
+type gate struct {
+	entered chan struct{}
+	open    chan struct{}
+	once    sync.Once
+	buf     bytes.Buffer
+}
+
+func newGate() *gate { return &gate{entered: make(chan struct{}), open: make(chan struct{})} }
+
+func (g *gate) Write(p []byte) (int, error) {
+	g.once.Do(func() { close(g.entered) })
+	<-g.open
+	return g.buf.Write(p)
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	for _, c := range []struct {
+		overflow Overflow
+		want     string
+	}{
+		{OverflowDropNewest, "i=1\ni=2\ni=3\nlevel=warn dropped=3\n"},
+		{OverflowDropOldest, "i=1\ni=5\ni=6\nlevel=warn dropped=3\n"},
+		{OverflowBlock, "i=1\ni=2\ni=3\ni=4\ni=5\ni=6\n"},
+	} {
+		g := newGate()
+		logger := NewAsync(g, 2, c.overflow)
+		logger.Log(*D("i", 1))
+		<-g.entered // the writer goroutine is blocked on i=1
+
+		done := make(chan struct{})
+		go func() {
+			for i := 2; i <= 6; i++ {
+				logger.Log(*D("i", i))
+			}
+			close(done)
+		}()
+		if c.overflow != OverflowBlock {
+			<-done // the queue is full, records have been dropped
+		}
+		close(g.open)
+		<-done
+		if err := logger.Close(); err != nil {
+			t.Errorf("overflow %d: Close() error %v", c.overflow, err)
+		}
+		if got := g.buf.String(); got != c.want {
+			t.Errorf("overflow %d: got\n%s\nwant\n%s", c.overflow, got, c.want)
+		}
+	}
+}
+
+func TestAsyncClose(t *testing.T) {
+	// records logged concurrently are all written on Close, records logged afterwards are discarded
+	var buf bytes.Buffer
+	logger := NewAsync(&buf, 4, OverflowBlock)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(l *Logger) {
+			for j := 0; j < 100; j++ {
+				l.Log(*D("j", j))
+			}
+			wg.Done()
+		}(logger.With(*D("i", i)))
+	}
+	wg.Wait()
+	logger.Close()
+	logger.Log(*K("late"))
+	logger.Flush()
+	if n := strings.Count(buf.String(), "\n"); n != 1000 {
+		t.Errorf("got %d lines want 1000", n)
+	}
+	if strings.Contains(buf.String(), "late") {
+		t.Errorf("record logged after Close has been written")
+	}
+}
+
+func BenchmarkAsyncLogger(b *testing.B) {
+	var buf bytes.Buffer
+	logger := NewAsync(&buf, 1024, OverflowBlock)
+	defer logger.Close()
+	for i := 0; i < b.N; i++ {
+		logger.Log(*D("at", i).Q("username", "eric").K("debug"))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/etnz/logfmt"
@@ -12,8 +13,16 @@ import (
 )
 
 var (
-	debug = flag.Bool("v", false, "set to true to print out extra log (lrep self logs) all with the lrep attribute")
-	help  = flag.Bool("h", false, "display some help")
+	debug  = flag.Bool("v", false, "set to true to print out extra log (lrep self logs) all with the lrep attribute")
+	help   = flag.Bool("h", false, "display some help")
+	strict = flag.Bool("strict", false, "skip lines that do not strictly conform to logfmt (reported as read-error with -v)")
+	pretty = flag.Bool("pretty", false, "print the records in a human friendly format, colored on a terminal")
+
+	redactKeys   = flag.String("redact-keys", "", "comma separated list of `keys` whose values are masked")
+	redactKey    = flag.String("redact-key", "", "mask the values of the keys matching this `regexp`")
+	redactValue  = flag.String("redact-value", "", "mask the parts of the values matching this `regexp`")
+	redactCommon = flag.Bool("redact-common", false, "mask bearer tokens and card numbers")
+	redactHash   = flag.Bool("redact-hash", false, "replace masked values by their hash, keyed for this run only")
 )
 
 func main() {
@@ -24,57 +33,87 @@ func main() {
 		return
 	}
 
-	if len(flag.Args()) != 1 {
+	if len(flag.Args()) > 1 {
 		flag.Usage()
 		fmt.Fprintf(os.Stderr, "Expecting a single query expression, got %v arguments instead.\n", flag.Args())
 		os.Exit(-1)
 	}
 
-	q := flag.Arg(0)
 	cmd := os.Args[0]
-
-	// start the job by parsing the ql expression
-	x, err := ql.Parse(strings.NewReader(q))
+	red, err := redactor()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid query:\n    %q\n    %v\n", q, err)
-		os.Exit(-2)
+		fmt.Fprintf(os.Stderr, "Invalid redaction: %v\n", err)
+		os.Exit(-1)
 	}
-	if *debug {
-		logfmt.
-			K(cmd).
-			Q("query", ql.Fmt(x)).
-			Log()
+	if *pretty {
+		logfmt.Default = logfmt.NewConsole(os.Stderr)
+	}
+	logfmt.Default.SetRedactor(red)
+
+	// start the job by parsing the ql expression, without query all records match
+	var x ql.Expr
+	if q := flag.Arg(0); q != "" {
+		x, err = ql.Parse(strings.NewReader(q))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid query:\n    %q\n    %v\n", q, err)
+			os.Exit(-2)
+		}
+		if *debug {
+			logfmt.
+				K(cmd).
+				Q("query", ql.Fmt(x)).
+				Log()
+		}
 	}
 
 	//and now read from the stdin for logfmt
-	reader := logreader.New(os.Stdin)
+	var reader logreader.FieldsReader
+	if *strict {
+		reader = logreader.NewStrict(os.Stdin)
+	} else {
+		reader = logreader.New(os.Stdin)
+	}
+	stats := newReadStats()
 	for reader.HasNext() {
-		//read the next record
-		rec, err := reader.Next()
+		//read the next record, in order, to print it out unchanged
+		fields, err := reader.NextFields()
+		stats.count(err)
 		if err != nil {
 			if *debug {
-
-				logfmt.
+				r := logfmt.
 					K(cmd).
-					K("read-error").
-					Q("error", err.Error()).
-					Log()
+					K("read-error")
+				if perr, ok := err.(*logreader.ParseError); ok {
+					// locate the error, and report the actual cause
+					r.D("line", perr.Line).
+						D("column", perr.Column).
+						Q("text", perr.Text)
+					err = perr.Err
+				}
+				r.Q("error", err.Error()).Log()
 			}
 			continue
 		}
 
 		//print out the next if it matches
-		match, err := ql.AsBool(ql.Eval(x, rec))
+		match := true
+		if x != nil {
+			match, err = ql.AsBool(ql.Eval(x, fields.Record()))
+		}
 		switch {
 
+		case err == nil && match && red == nil && !*pretty:
+			// print it out unchanged
+			os.Stderr.Write(append(reader.Line(), '\n'))
+
 		case err == nil && match:
 			//simply log it
-			logfmt.Default.Log(rec)
+			logfmt.Default.LogFields(fields)
 
 		case err != nil && *debug:
 			// error in debug mode: simply print the "faulty" log record and the error
 
-			logfmt.Default.Log(rec)
+			logfmt.Default.LogFields(fields)
 			logfmt.
 				K(cmd).
 				K("runtime-error").
@@ -82,6 +121,91 @@ func main() {
 				Log()
 		}
 	}
+	stats.log(cmd)
+}
+
+// redactor returns the Redactor configured by the flags, nil if there is none
+func redactor() (*logfmt.Redactor, error) {
+	r := &logfmt.Redactor{Hash: *redactHash}
+	if *redactKeys != "" {
+		r.Keys = strings.Split(*redactKeys, ",")
+	}
+	if *redactKey != "" {
+		p, err := regexp.Compile(*redactKey)
+		if err != nil {
+			return nil, err
+		}
+		r.KeyPatterns = append(r.KeyPatterns, p)
+	}
+	if *redactValue != "" {
+		p, err := regexp.Compile(*redactValue)
+		if err != nil {
+			return nil, err
+		}
+		r.ValuePatterns = append(r.ValuePatterns, p)
+	}
+	if *redactCommon {
+		r.ValuePatterns = append(r.ValuePatterns, logfmt.BearerTokenPattern, logfmt.CardNumberPattern)
+	}
+	if len(r.Keys)+len(r.KeyPatterns)+len(r.ValuePatterns) == 0 {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// readStats counts the lines that failed to be read, by cause.
+type readStats struct {
+	lines, failed int
+	causes        []string       // in order of first occurrence
+	failures      map[string]int // number of failures per cause
+	first         map[string]int // first line for each cause
+}
+
+func newReadStats() *readStats {
+	return &readStats{
+		failures: make(map[string]int),
+		first:    make(map[string]int),
+	}
+}
+
+// count a line read with error 'err' (possibly nil)
+func (s *readStats) count(err error) {
+	s.lines++
+	if err == nil {
+		return
+	}
+	s.failed++
+	cause, line := err.Error(), s.lines
+	if perr, ok := err.(*logreader.ParseError); ok {
+		cause, line = perr.Err.Error(), perr.Line
+	}
+	if _, exists := s.failures[cause]; !exists {
+		s.causes = append(s.causes, cause)
+		s.first[cause] = line
+	}
+	s.failures[cause]++
+}
+
+// log the summary, if any line has failed.
+func (s *readStats) log(cmd string) {
+	if s.failed == 0 {
+		return
+	}
+	logfmt.
+		K(cmd).
+		K("read-summary").
+		D("lines", s.lines).
+		D("failed", s.failed).
+		Log()
+	for _, cause := range s.causes {
+		logfmt.
+			K(cmd).
+			K("read-summary").
+			Q("error", cause).
+			D("failed", s.failures[cause]).
+			D("first-line", s.first[cause]).
+			Log()
+	}
 }
 
 func Usage() {
@@ -0,0 +1,199 @@
+package logfmt
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// the prefix attributes of a Console line
+const (
+	timeKey = "time"
+	msgKey  = "msg"
+)
+
+// ANSI escape codes
+const (
+	faint   = "\x1b[2m"
+	bold    = "\x1b[1m"
+	red     = "\x1b[31m"
+	green   = "\x1b[32m"
+	yellow  = "\x1b[33m"
+	blue    = "\x1b[34m"
+	magenta = "\x1b[35m"
+	cyan    = "\x1b[36m"
+	noColor = "\x1b[0m"
+)
+
+// levelColors are the colors of the LevelKey values
+var levelColors = map[string]string{
+	"debug": blue,
+	"info":  green,
+	"warn":  yellow,
+	"error": red,
+}
+
+// Console is a human friendly format of the Logger lines, for developers.
+//
+// The 'time', 'level' and 'msg' attributes come first, unquoted, and aligned:
+//
+//    12:00:00.000 INFO  started                        user=bob port=8080
+//    12:00:01.250 ERROR request failed                 path=/ status=500
+//
+// The other attributes follow, in the Logger's order.
+type Console struct {
+	Color      bool   // color keys, levels and values with ANSI escape codes
+	TimeFormat string // the layout of the 'time' values, as is if empty
+	MsgWidth   int    // the 'msg' values are padded to MsgWidth
+	Wrap       int    // values longer than Wrap are written unquoted, on their own lines, after the others; never if zero
+}
+
+// NewConsole creates a new Logger writing to 'out' in Console format, colored
+// if 'out' is a terminal.
+func NewConsole(out io.Writer) *Logger {
+	l := New(out)
+	l.SetConsole(&Console{
+		Color:      IsTerminal(out),
+		TimeFormat: "15:04:05.000",
+		MsgWidth:   30,
+		Wrap:       120,
+	})
+	return l
+}
+
+// IsTerminal returns true if 'w' is a terminal, and colors are not disabled
+// by the NO_COLOR environment variable.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// SetConsole sets the Console format of the lines, nil to write logfmt.
+//
+// Child Loggers created afterwards inherit it.
+func (l *Logger) SetConsole(c *Console) {
+	l.lock.Lock()
+	l.console = c
+	l.lock.Unlock()
+}
+
+// writeTo writes the sorted attributes, in logfmt if 'c' is nil.
+func (c *Console) writeTo(buf writer, s *fastKeySorter) {
+	if c == nil {
+		s.writeTo(buf)
+		return
+	}
+	c.write(buf, s.keys, s.vals)
+}
+
+// fieldsTo writes the Fields, in logfmt if 'c' is nil.
+func (c *Console) fieldsTo(buf writer, f Fields) {
+	if c == nil {
+		fieldsTo(buf, f)
+		return
+	}
+	s := getSorter()
+	for _, p := range f {
+		s.add(p.Key, p.Val)
+	}
+	c.write(buf, s.keys, s.vals)
+	putSorter(s)
+}
+
+// write the attributes in order, after the prefix attributes
+func (c *Console) write(buf writer, keys []string, vals []*string) {
+	p := printer{buf: buf, color: c.Color}
+	prefix := func(key string) (string, bool) {
+		for i, k := range keys {
+			if k == key && vals[i] != nil {
+				return unquote(*vals[i]), true
+			}
+		}
+		return "", false
+	}
+	if t, ok := prefix(timeKey); ok {
+		if c.TimeFormat != "" {
+			if tt, err := parseTime(t); err == nil {
+				t = tt.Format(c.TimeFormat)
+			}
+		}
+		p.print(faint, t, 0)
+	}
+	if level, ok := prefix(LevelKey); ok {
+		p.print(levelColors[level], strings.ToUpper(level), len("error"))
+	}
+	if msg, ok := prefix(msgKey); ok {
+		p.print(bold, msg, c.MsgWidth)
+	}
+
+	var long []int // the attributes written last
+	for i, k := range keys {
+		val := vals[i]
+		if val != nil && (k == timeKey || k == LevelKey || k == msgKey) {
+			continue
+		}
+		if c.Wrap > 0 && val != nil && len(*val) > c.Wrap {
+			long = append(long, i)
+			continue
+		}
+		p.attr(k, val)
+	}
+	for _, i := range long {
+		p.pad = 0
+		buf.WriteString("\n    ")
+		p.paint(cyan, keys[i]+":")
+		for _, line := range strings.Split(unquote(*vals[i]), "\n") {
+			buf.WriteString("\n        ")
+			p.paint(magenta, line)
+		}
+	}
+}
+
+// printer writes the items of a Console line
+type printer struct {
+	buf   writer
+	color bool
+	items int // the number of items written
+	pad   int // the padding of the last item, written before the next one
+}
+
+// print an item, padded to 'width'
+func (p *printer) print(color, s string, width int) {
+	p.sep()
+	p.paint(color, s)
+	p.pad = width - len(s)
+}
+
+// attr prints an attribute
+func (p *printer) attr(key string, val *string) {
+	p.sep()
+	p.paint(cyan, key)
+	if val != nil {
+		p.paint(faint, "=")
+		p.paint(magenta, *val)
+	}
+	p.pad = 0
+}
+
+// sep writes the padding of the last item, and a separator
+func (p *printer) sep() {
+	if p.items > 0 {
+		p.buf.WriteString(strings.Repeat(" ", max(p.pad, 0)+1))
+	}
+	p.items++
+}
+
+// paint writes 's' in 'color', if enabled
+func (p *printer) paint(color, s string) {
+	if !p.color || color == "" {
+		p.buf.WriteString(s)
+		return
+	}
+	p.buf.WriteString(color)
+	p.buf.WriteString(s)
+	p.buf.WriteString(noColor)
+}
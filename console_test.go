@@ -0,0 +1,73 @@
+package logfmt_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/etnz/logfmt"
+)
+
+func ExampleConsole() {
+	logger := logfmt.New(os.Stdout)
+	logger.SetConsole(&logfmt.Console{TimeFormat: "15:04:05.000", MsgWidth: 16, Wrap: 20})
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	logger.Info(*logfmt.Time("time", at).S("msg", "started").D("port", 8080))
+	logger.Error(*logfmt.Time("time", at.Add(1250*time.Millisecond)).S("msg", "request failed").S("path", "/").
+		Q("stack", "main.handle()\n\tmain.go:12"))
+	logger.Log(*logfmt.S("user", "bob"))
+
+	//Output:
+	// 12:00:00.000 INFO  started          port=8080
+	// 12:00:01.250 ERROR request failed   path=/
+	//     stack:
+	//         main.handle()
+	//         	main.go:12
+	// user=bob
+}
+
+func TestConsoleColor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logfmt.New(&buf)
+	logger.SetConsole(&logfmt.Console{Color: true, Wrap: 10})
+	logger.Warn(*logfmt.S("msg", "slow").K("cached").D("ms", 1500))
+	logger.Log(*logfmt.Q("stack", "main.go:12\nmain.go:3"))
+
+	want := "\x1b[33mWARN\x1b[0m  \x1b[1mslow\x1b[0m \x1b[36mms\x1b[0m\x1b[2m=\x1b[0m\x1b[35m1500\x1b[0m \x1b[36mcached\x1b[0m\n" +
+		"\n    \x1b[36mstack:\x1b[0m\n        \x1b[35mmain.go:12\x1b[0m\n        \x1b[35mmain.go:3\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestConsoleAsync(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logfmt.NewAsync(&buf, 16, logfmt.OverflowBlock)
+	logger.SetConsole(&logfmt.Console{})
+	logger.Info(*logfmt.S("msg", "started").Err("error", errors.New("none")))
+	msg, n := "fields", "1"
+	logger.LogFields(logfmt.Fields{{Key: "msg", Val: &msg}, {Key: "n", Val: &n}})
+	logger.Close()
+
+	want := "INFO  started error=\"none\"\nfields n=1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	if logfmt.IsTerminal(&bytes.Buffer{}) {
+		t.Error("a buffer is not a terminal")
+	}
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if logfmt.IsTerminal(f) {
+		t.Error("a regular file is not a terminal")
+	}
+}
@@ -46,6 +46,16 @@
 //
 // There are basically only one new "words" to learn: 'K' stands for Key only.
 //
+// Common types have their own builders: Int64, Uint64, Time (in TimeFormat),
+// Duration, Err (with the error root cause), Hex and Base64 for []byte.
+//
+// Types control how V logs them by implementing Marshaler, possibly as
+// several sub-keys, encoding.TextMarshaler or fmt.Stringer.
+//
+// FromStruct and Record.Struct flatten structs and maps into dotted keys,
+// configured by 'logfmt' struct tags. Unmarshal does the opposite: it stores
+// a Record into a struct.
+//
 // Records are then created using a sequence of call to one of these functions.
 //
 //    Q("user", username).D("retry", retryCount).K("debug").Log()
@@ -56,5 +66,51 @@
 // 'significance' order. It means that general keys (short name) come first then
 // specific attributes (long name).
 //
-// This Log method is fitted for 'defer'.
+// This Log method is fitted for 'defer'. So is End, that logs a Span with the
+// time elapsed since its start, and its outcome:
+//
+//    defer Span("db.query").S("table", table).End(&err)
+//
+// Other orders can be set on a Logger (see KeyOrder): Alphabetical, or a
+// Priority list of keys written first, like 'time level msg'.
+//
+// Levels
+//
+// Records can also be logged at a given Level, using Debug, Info, Warn or Error
+// instead of Log. The Level is written in the 'level' attribute.
+//
+//    Q("user", username).Warn()
+//
+// A Logger drops records below its minimum Level (see SetLevel), before they
+// are even formatted.
+//
+// Child Loggers
+//
+// With returns a child Logger that binds attributes to every record it logs,
+// sharing its parent output, lock and level.
+//
+//    req := Default.With(*S("request_id", id).S("user", username))
+//    req.Info(*S("msg", "started"))
+//
+// A Sampler suppresses noisy records, see Sampling and RateLimit. A Redactor
+// masks sensitive values. A Filter selects the records to write, and Tee
+// writes records to several Loggers (see package fanout for routing rules
+// written as ql queries).
+//
+// NewConsole returns a Logger writing in a human friendly Console format, for
+// developers: colored on a terminal, with aligned 'time', 'level' and 'msg'.
+//
+// Asynchronous Loggers
+//
+// NewAsync returns a Logger that writes records in a background goroutine,
+// through a bounded queue. Its Overflow policy decides what to do when the
+// queue is full. Flush waits for the queued records to be written, Close
+// drains the queue on shutdown.
+//
+// log/slog
+//
+// Handler is a slog.Handler writing to a Logger, groups are written as dotted
+// keys.
+//
+//    slog.SetDefault(slog.New(NewHandler(Default)))
 package logfmt
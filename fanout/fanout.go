@@ -0,0 +1,61 @@
+// Package fanout writes logfmt records to several destinations, each with its
+// own routing rule, written in the lrep query language (see package ql).
+//
+//    logger, err := fanout.New(
+//        fanout.Sink{Out: file},
+//        fanout.Sink{Out: os.Stderr, Console: &logfmt.Console{Color: true}, Query: ".level ~ /error/"},
+//    )
+package fanout
+
+import (
+	"io"
+	"strings"
+
+	"github.com/etnz/logfmt"
+	"github.com/etnz/logfmt/ql"
+)
+
+// Sink is a destination of a fan-out Logger.
+type Sink struct {
+	Out     io.Writer
+	Order   logfmt.KeyOrder // the attributes order, see logfmt.Logger.SetOrder
+	Console *logfmt.Console // the line format, logfmt if nil, see logfmt.Logger.SetConsole
+	Level   logfmt.Level    // the minimum Level of the leveled records
+	Query   string          // the ql expression records must match, all records if empty
+}
+
+// New returns a Logger writing every record to each of 'sinks' matching it.
+//
+// It returns an error if a Query is not valid.
+func New(sinks ...Sink) (*logfmt.Logger, error) {
+	loggers := make([]*logfmt.Logger, len(sinks))
+	for i, s := range sinks {
+		l := logfmt.New(s.Out)
+		l.SetOrder(s.Order)
+		l.SetConsole(s.Console)
+		l.SetLevel(s.Level)
+		if s.Query != "" {
+			f, err := Match(s.Query)
+			if err != nil {
+				return nil, err
+			}
+			l.SetFilter(f)
+		}
+		loggers[i] = l
+	}
+	return logfmt.Tee(loggers...), nil
+}
+
+// Match returns a Filter of the records matching the ql expression 'query'.
+//
+// Records the query cannot be evaluated on do not match.
+func Match(query string) (logfmt.Filter, error) {
+	x, err := ql.Parse(strings.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	return func(rec logfmt.Record) bool {
+		match, err := ql.AsBool(ql.Eval(x, rec))
+		return err == nil && match
+	}, nil
+}
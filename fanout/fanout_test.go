@@ -0,0 +1,70 @@
+package fanout
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/etnz/logfmt"
+)
+
+func ExampleNew() {
+	var file bytes.Buffer
+	logger, err := New(
+		Sink{Out: &file},
+		Sink{Out: os.Stdout, Query: ".level ~ /error/"},
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	logger.Info(*logfmt.S("msg", "started"))
+	logger.Error(*logfmt.S("msg", "failed"))
+	fmt.Print(file.String())
+
+	//Output:
+	// msg=failed level=error
+	// msg=started level=info
+	// msg=failed level=error
+}
+
+func TestSinks(t *testing.T) {
+	var all, slow, warn, console bytes.Buffer
+	logger, err := New(
+		Sink{Out: &all, Order: logfmt.Alphabetical},
+		Sink{Out: &console, Console: &logfmt.Console{}, Level: logfmt.LevelWarn, Query: ".msg ?"},
+		Sink{Out: &slow, Query: ".elapsed > 100"},
+		Sink{Out: &warn, Level: logfmt.LevelWarn},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := logger.With(*logfmt.S("path", "/"))
+	req.Log(*logfmt.D("elapsed", 150))
+	req.Info(*logfmt.D("elapsed", 12))
+	req.Warn(*logfmt.S("msg", "slow"))
+	v := "300"
+	req.LogFields(logfmt.Fields{{Key: "elapsed", Val: &v}})
+	logger.Close()
+
+	for _, c := range []struct {
+		name      string
+		got, want string
+	}{
+		{"all", all.String(), "elapsed=150 path=/\nelapsed=12 level=info path=/\nlevel=warn msg=slow path=/\nelapsed=300 path=/\n"},
+		{"slow", slow.String(), "path=/ elapsed=150\npath=/ elapsed=300\n"},
+		{"console", console.String(), "WARN  slow path=/\n"},
+		{"warn", warn.String(), "path=/ elapsed=150\nmsg=slow path=/ level=warn\npath=/ elapsed=300\n"}, // records without level are written
+	} {
+		if c.got != c.want {
+			t.Errorf("%s: got %q want %q", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestInvalidQuery(t *testing.T) {
+	if _, err := New(Sink{Out: os.Stdout, Query: ".a <"}); err == nil {
+		t.Errorf("an invalid query must fail")
+	}
+}
@@ -0,0 +1,80 @@
+package logfmt
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// Pair is a single attribute: a key and its value, nil for a key only attribute.
+type Pair struct {
+	Key string
+	Val *string
+}
+
+// Fields is an ordered Record: attributes are kept in order, duplicated keys
+// included.
+//
+// It is suitable to read and write back a logfmt line exactly as it was.
+type Fields []Pair
+
+// Log this Fields now into the 'Default' Logger
+func (f Fields) Log() { Default.LogFields(f) }
+
+// Get returns the value of the last attribute named 'key', like a map lookup.
+func (f Fields) Get(key string) (val *string, exists bool) {
+	for i := len(f) - 1; i >= 0; i-- {
+		if f[i].Key == key {
+			return f[i].Val, true
+		}
+	}
+	return nil, false
+}
+
+// Add appends an attribute, even if 'key' already exists.
+func (f *Fields) Add(key string, val *string) *Fields {
+	*f = append(*f, Pair{Key: key, Val: val})
+	return f
+}
+
+// Set replaces the value of the last attribute named 'key', or appends it.
+func (f *Fields) Set(key string, val *string) *Fields {
+	for i := len(*f) - 1; i >= 0; i-- {
+		if (*f)[i].Key == key {
+			(*f)[i].Val = val
+			return f
+		}
+	}
+	return f.Add(key, val)
+}
+
+// Record returns these Fields as a Record, the last value of duplicated keys is kept.
+func (f Fields) Record() Record {
+	rec := make(Record, len(f))
+	for _, p := range f {
+		rec[p.Key] = p.Val
+	}
+	return rec
+}
+
+// String format the Fields as a string, in order
+func (f Fields) String() string {
+	var buffer bytes.Buffer
+	buf := bufio.NewWriter(&buffer)
+	fieldsTo(buf, f)
+	buf.Flush()
+	return buffer.String()
+}
+
+// fieldsTo log Fields into the buf, in order
+func fieldsTo(buf writer, f Fields) {
+	for i, p := range f {
+		if i > 0 {
+			buf.WriteRune(' ')
+		}
+		buf.WriteString(p.Key)
+		if p.Val != nil {
+			buf.WriteRune('=')
+			buf.WriteString(*p.Val)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package logfmt
+
+import (
+	"fmt"
+	"os"
+)
+
+func ExampleFields() {
+	Default = New(os.Stdout)
+
+	one, two := "1", `"two"`
+	var f Fields
+	f.Add("time", &one).Add("msg", &two).Add("debug", nil).Add("time", &two)
+	f.Log()
+
+	val, _ := f.Get("time") // the last one, like in a map
+	fmt.Println(*val)
+	fmt.Println(f.Record())
+	//Output:
+	// time=1 msg="two" debug time="two"
+	// "two"
+	// msg="two" time="two" debug
+}
+
+func ExampleFields_Set() {
+	one, two := "1", "2"
+	var f Fields
+	f.Set("b", &one).Set("a", &one).Set("b", &two)
+	fmt.Println(f)
+	//Output: b=2 a=1
+}
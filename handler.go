@@ -0,0 +1,122 @@
+package logfmt
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Handler is a slog.Handler that writes records to a Logger.
+//
+//    slog.SetDefault(slog.New(logfmt.NewHandler(logfmt.Default)))
+//
+// The Logger's Level applies, slog levels are mapped to the nearest lower
+// Level: slog.LevelInfo+2 is logged at LevelInfo.
+//
+// Built-in attributes are written in the 'time', 'level' and 'msg' keys, the
+// time in TimeFormat. Errors are written with their root cause, see Record.Err.
+// Groups are written as dotted key prefixes: 'req.method=GET'. String values
+// follow the Record.S quoting rules.
+type Handler struct {
+	logger *Logger // the Logger the attributes are bound to
+	prefix string  // the current groups, as a dotted prefix
+}
+
+// NewHandler returns a Handler writing to 'l'.
+func NewHandler(l *Logger) *Handler { return &Handler{logger: l} }
+
+// Enabled returns true if the Logger writes records at 'level'.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(levelOf(level))
+}
+
+// Handle writes the slog.Record to the Logger.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	rec := make(Record, r.NumAttrs()+2)
+	if !r.Time.IsZero() {
+		rec.Time(slog.TimeKey, r.Time)
+	}
+	rec.S(slog.MessageKey, r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		rec.attr(h.prefix, a)
+		return true
+	})
+	h.logger.LogLevel(levelOf(r.Level), rec)
+	return nil
+}
+
+// WithAttrs returns a Handler whose Logger is a child Logger, with 'attrs' bound.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	rec := make(Record, len(attrs))
+	for _, a := range attrs {
+		rec.attr(h.prefix, a)
+	}
+	return &Handler{logger: h.logger.With(rec), prefix: h.prefix}
+}
+
+// WithGroup returns a Handler that prefixes the keys of the next attributes with 'name.'
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+// levelOf returns the Level of a slog level
+func levelOf(l slog.Level) Level {
+	switch {
+	case l < slog.LevelInfo:
+		return LevelDebug
+	case l < slog.LevelWarn:
+		return LevelInfo
+	case l < slog.LevelError:
+		return LevelWarn
+	default:
+		return LevelError
+	}
+}
+
+// attr inserts a slog attribute, its key prefixed by 'prefix'.
+//
+// Empty attributes and empty groups are ignored, groups without a key are inlined.
+func (rec *Record) attr(prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, g := range v.Group() {
+			rec.attr(prefix, g)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+
+	key := prefix + a.Key
+	switch v.Kind() {
+	case slog.KindString:
+		rec.S(key, v.String())
+	case slog.KindInt64:
+		rec.Int64(key, v.Int64())
+	case slog.KindUint64:
+		rec.Uint64(key, v.Uint64())
+	case slog.KindFloat64:
+		rec.G(key, v.Float64())
+	case slog.KindBool:
+		rec.T(key, v.Bool())
+	case slog.KindDuration:
+		rec.Duration(key, v.Duration())
+	case slog.KindTime:
+		rec.Time(key, v.Time())
+	default:
+		if err, ok := v.Any().(error); ok {
+			rec.Err(key, err)
+			return
+		}
+		rec.V(key, v.Any())
+	}
+}
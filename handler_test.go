@@ -0,0 +1,83 @@
+package logfmt_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/etnz/logfmt"
+	"github.com/etnz/logfmt/logreader"
+)
+
+func ExampleHandler() {
+	h := logfmt.NewHandler(logfmt.New(os.Stdout))
+	logger := slog.New(h).With("service", "auth").WithGroup("req")
+
+	// records are usually created by the slog.Logger, with the current time
+	r := slog.NewRecord(time.Time{}, slog.LevelWarn, "slow request", 0)
+	r.AddAttrs(slog.String("method", "GET"), slog.Duration("elapsed", 1500*time.Millisecond))
+	logger.Handler().Handle(context.Background(), r)
+
+	//Output:
+	// msg="slow request" level=warn service=auth req.method=GET req.elapsed=1.5s
+}
+
+func TestHandlerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := logfmt.New(&buf)
+	l.SetLevel(logfmt.LevelWarn)
+	logger := slog.New(logfmt.NewHandler(l))
+	logger.Info("dropped")
+	logger.Log(context.Background(), slog.LevelWarn+2, "kept")
+	if got := buf.String(); strings.Contains(got, "dropped") || !strings.Contains(got, "level=warn") {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestSlog(t *testing.T) {
+	var buf bytes.Buffer
+	h := logfmt.NewHandler(logfmt.New(&buf))
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			fields, err := logreader.ParseFields(line)
+			if err != nil {
+				t.Fatalf("cannot parse %q: %v", line, err)
+			}
+			m := make(map[string]any)
+			for _, p := range fields {
+				// dotted keys are groups
+				keys := strings.Split(p.Key, ".")
+				g := m
+				for _, k := range keys[:len(keys)-1] {
+					sub, ok := g[k].(map[string]any)
+					if !ok {
+						sub = make(map[string]any)
+						g[k] = sub
+					}
+					g = sub
+				}
+				var val any
+				if p.Val != nil {
+					val = *p.Val
+					if s, err := strconv.Unquote(*p.Val); err == nil {
+						val = s
+					}
+				}
+				g[keys[len(keys)-1]] = val
+			}
+			ms = append(ms, m)
+		}
+		return ms
+	}
+	if err := slogtest.TestHandler(h, results); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,62 @@
+package logfmt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LevelKey is the key used to write the Level of a Record.
+const LevelKey = "level"
+
+// Level is the severity of a Record.
+type Level int32
+
+// Levels, in increasing severity
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// levels values, as written in the LevelKey attribute
+var levels = [...]string{"debug", "info", "warn", "error"}
+
+// String returns the level value, as written in a Record.
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int32(l))
+	}
+	return levels[l]
+}
+
+// value returns the level value as stored in a Record
+func (l Level) value() *string {
+	if l < LevelDebug || l > LevelError {
+		s := l.String()
+		return &s
+	}
+	return &levels[l]
+}
+
+// ParseLevel returns the Level named 's' (case insensitive).
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levels {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return LevelDebug, fmt.Errorf("unknown level %q", s)
+}
+
+// Debug logs this Record now into the 'Default' Logger, at debug level
+func (rec Record) Debug() { Default.Debug(rec) }
+
+// Info logs this Record now into the 'Default' Logger, at info level
+func (rec Record) Info() { Default.Info(rec) }
+
+// Warn logs this Record now into the 'Default' Logger, at warn level
+func (rec Record) Warn() { Default.Warn(rec) }
+
+// Error logs this Record now into the 'Default' Logger, at error level
+func (rec Record) Error() { Default.Error(rec) }
@@ -0,0 +1,321 @@
+// Package logfmttest helps testing code that logs: it records the lines of a
+// Logger, and asserts on them with ql queries, or against a golden file.
+//
+//    func TestLogin(t *testing.T) {
+//        rec := logfmttest.Capture(t) // records logfmt.Default
+//        login("eric")
+//        rec.Expect(t, `.msg ~ /logged in/ and .user ~ /^eric$/`)
+//    }
+package logfmttest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/etnz/logfmt"
+	"github.com/etnz/logfmt/fanout"
+	"github.com/etnz/logfmt/logreader"
+)
+
+// Update makes Golden write the golden files, instead of comparing them.
+var Update = flag.Bool("logfmttest.update", false, "update the logfmttest golden files")
+
+// Recorder is an io.Writer that keeps the lines written by a Logger, parsed.
+//
+// It is safe for concurrent use.
+type Recorder struct {
+	lock    sync.Mutex // guards the fields below
+	lines   []logfmt.Fields
+	partial []byte // the beginning of a line, not written yet
+	err     error  // the first line that could not be parsed
+}
+
+// New returns a Logger writing to a new Recorder.
+func New() (*logfmt.Logger, *Recorder) {
+	r := new(Recorder)
+	return logfmt.New(r), r
+}
+
+// Capture replaces logfmt.Default by a Logger writing to a new Recorder, until
+// the end of the test.
+func Capture(t testing.TB) *Recorder {
+	logger, r := New()
+	saved := logfmt.Default
+	logfmt.Default = logger
+	t.Cleanup(func() { logfmt.Default = saved })
+	return r
+}
+
+// Write parses each line of 'p'. An unterminated line is parsed when it is
+// completed by the next Write. Lines that cannot be parsed are skipped, see Err.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.partial = append(r.partial, p...)
+	for {
+		i := bytes.IndexByte(r.partial, '\n')
+		if i < 0 {
+			break
+		}
+		f, err := logreader.ParseFields(string(r.partial[:i]))
+		r.partial = r.partial[i+1:]
+		if err != nil {
+			if r.err == nil {
+				r.err = err
+			}
+			continue
+		}
+		r.lines = append(r.lines, f)
+	}
+	return len(p), nil
+}
+
+// Err returns the error of the first line that could not be parsed, if any.
+func (r *Recorder) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+// Fields returns the lines written so far, in order.
+func (r *Recorder) Fields() []logfmt.Fields {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]logfmt.Fields(nil), r.lines...)
+}
+
+// Records returns the records written so far, in order.
+func (r *Recorder) Records() []logfmt.Record {
+	lines := r.Fields()
+	records := make([]logfmt.Record, len(lines))
+	for i, f := range lines {
+		records[i] = f.Record()
+	}
+	return records
+}
+
+// Reset forgets the lines written so far, and the error.
+func (r *Recorder) Reset() {
+	r.lock.Lock()
+	r.lines, r.partial, r.err = nil, nil, nil
+	r.lock.Unlock()
+}
+
+// String returns the lines written so far.
+func (r *Recorder) String() string {
+	var buf strings.Builder
+	for _, f := range r.Fields() {
+		buf.WriteString(f.String())
+		buf.WriteRune('\n')
+	}
+	return buf.String()
+}
+
+// Match returns the records matching the ql 'query'.
+func (r *Recorder) Match(query string) ([]logfmt.Record, error) {
+	match, err := fanout.Match(query)
+	if err != nil {
+		return nil, err
+	}
+	var records []logfmt.Record
+	for _, rec := range r.Records() {
+		if match(rec) {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// Contains fails the test if no record matches the ql 'query'.
+func (r *Recorder) Contains(t testing.TB, query string) {
+	t.Helper()
+	records, err := r.Match(query)
+	if err != nil {
+		t.Fatalf("invalid query %q: %v", query, err)
+		return
+	}
+	if len(records) == 0 {
+		t.Errorf("no record matches %q, got:\n%s", query, r)
+	}
+}
+
+// Absent fails the test if a record matches the ql 'query'.
+func (r *Recorder) Absent(t testing.TB, query string) {
+	t.Helper()
+	records, err := r.Match(query)
+	if err != nil {
+		t.Fatalf("invalid query %q: %v", query, err)
+		return
+	}
+	if len(records) > 0 {
+		t.Errorf("%d records match %q, want none, first: %v", len(records), query, records[0])
+	}
+}
+
+// Expect fails the test unless each of the ql 'queries' matches a distinct
+// record, in any order.
+func (r *Recorder) Expect(t testing.TB, queries ...string) {
+	t.Helper()
+	matches, ok := r.matches(t, queries)
+	if !ok {
+		return
+	}
+	// assign records to queries, looking for an augmenting path for each query
+	owner := make(map[int]int) // the query of each assigned record
+	var assign func(q int, seen map[int]bool) bool
+	assign = func(q int, seen map[int]bool) bool {
+		for _, i := range matches[q] {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			if o, taken := owner[i]; !taken || assign(o, seen) {
+				owner[i] = q
+				return true
+			}
+		}
+		return false
+	}
+	for q, query := range queries {
+		if !assign(q, make(map[int]bool)) {
+			t.Errorf("no other record matches %q, got:\n%s", query, r)
+			return
+		}
+	}
+}
+
+// ExpectOrdered fails the test unless the ql 'queries' match records in that
+// order. Other records can be written in between.
+func (r *Recorder) ExpectOrdered(t testing.TB, queries ...string) {
+	t.Helper()
+	matches, ok := r.matches(t, queries)
+	if !ok {
+		return
+	}
+	next := 0 // the first record the next query can match
+	for q, query := range queries {
+		found := false
+		for _, i := range matches[q] {
+			if i >= next {
+				next, found = i+1, true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("no record matches %q after record %d, got:\n%s", query, next, r)
+			return
+		}
+	}
+}
+
+// matches returns the indexes of the records matching each query
+func (r *Recorder) matches(t testing.TB, queries []string) ([][]int, bool) {
+	t.Helper()
+	records := r.Records()
+	matches := make([][]int, len(queries))
+	for q, query := range queries {
+		match, err := fanout.Match(query)
+		if err != nil {
+			t.Fatalf("invalid query %q: %v", query, err)
+			return nil, false
+		}
+		for i, rec := range records {
+			if match(rec) {
+				matches[q] = append(matches[q], i)
+			}
+		}
+	}
+	return matches, true
+}
+
+// Golden compares the lines written so far, without the 'ignore' keys (like
+// 'time'), to the golden file 'name', and fails the test with a diff if they
+// differ.
+//
+// Run the tests with -logfmttest.update to write the golden file instead.
+func (r *Recorder) Golden(t testing.TB, name string, ignore ...string) {
+	t.Helper()
+	var got strings.Builder
+	for _, f := range r.Fields() {
+		kept := make(logfmt.Fields, 0, len(f))
+		for _, p := range f {
+			if !contains(ignore, p.Key) {
+				kept = append(kept, p)
+			}
+		}
+		got.WriteString(kept.String())
+		got.WriteRune('\n')
+	}
+
+	if *Update {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+			return
+		}
+		if err := os.WriteFile(name, []byte(got.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("%v (run with -logfmttest.update to create it)", err)
+		return
+	}
+	if got.String() != string(want) {
+		t.Errorf("lines differ from %s (-want +got):\n%s", name, Diff(string(want), got.String()))
+	}
+}
+
+// contains returns true if 'key' is one of 'keys'
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the line by line differences from 'want' to 'got': removed
+// lines are prefixed by '-', added lines by '+', and common lines by ' '.
+func Diff(want, got string) string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var diff strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&diff, "  %s\n", a[i])
+			i, j = i+1, j+1
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			fmt.Fprintf(&diff, "- %s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(&diff, "+ %s\n", b[j])
+			j++
+		}
+	}
+	return diff.String()
+}
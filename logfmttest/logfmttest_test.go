@@ -0,0 +1,125 @@
+package logfmttest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/etnz/logfmt"
+)
+
+// fakeT records the failures of a test
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (t *fakeT) Helper() {}
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+func (t *fakeT) Fatalf(format string, args ...interface{}) { t.Errorf(format, args...) }
+func (t *fakeT) Fatal(args ...interface{})                 { t.Errorf("%s", fmt.Sprint(args...)) }
+
+func ExampleDiff() {
+	fmt.Print(Diff("a=1\nb=2\nc=3\n", "a=1\nc=3\nd=4\n"))
+	//Output:
+	//   a=1
+	// - b=2
+	//   c=3
+	// + d=4
+}
+
+func TestCapture(t *testing.T) {
+	saved := logfmt.Default
+	t.Run("capture", func(t *testing.T) {
+		rec := Capture(t)
+		logfmt.S("msg", "started").S("user", "eric").Info()
+		logfmt.S("msg", "done").Log()
+
+		rec.Contains(t, `.user ~ /^eric$/`)
+		rec.Absent(t, `.level ~ /^error$/`)
+		if got, want := len(rec.Records()), 2; got != want {
+			t.Errorf("got %d records want %d", got, want)
+		}
+	})
+	if logfmt.Default != saved {
+		t.Error("logfmt.Default was not restored")
+	}
+}
+
+func TestInvalidLine(t *testing.T) {
+	logger, rec := New()
+	logger.Log(*logfmt.K(`a="x`))
+	logger.Log(*logfmt.S("msg", "a"))
+	logger.Log(*logfmt.S("msg", "b"))
+	if err := logger.Err(); err != nil {
+		t.Errorf("the logger got an error: %v", err)
+	}
+	if rec.Err() == nil {
+		t.Error("the invalid line must be reported")
+	}
+	if got := rec.String(); got != "msg=a\nmsg=b\n" {
+		t.Errorf("the lines after an invalid one must be recorded, got %q", got)
+	}
+	rec.Reset()
+	if rec.Err() != nil {
+		t.Error("Reset must forget the error")
+	}
+}
+
+func TestExpect(t *testing.T) {
+	logger, rec := New()
+	logger.Info(*logfmt.S("msg", "a"))
+	logger.Info(*logfmt.S("msg", "b"))
+	logger.Error(*logfmt.S("msg", "c"))
+
+	for _, test := range []struct {
+		ordered bool
+		queries []string
+		fail    bool
+	}{
+		{false, []string{`.msg ~ /^c$/`, `.msg ~ /^a$/`}, false},
+		{true, []string{`.msg ~ /^a$/`, `.msg ~ /^c$/`}, false},
+		{true, []string{`.msg ~ /^c$/`, `.msg ~ /^a$/`}, true},
+		{false, []string{`.level ~ /^info$/`, `.msg ~ /^a$/`}, false},                // b matches the first query
+		{false, []string{`.msg ~ /^a$/`, `.msg ~ /a/`}, true},                        // a single record matches both
+		{false, []string{`.level ~ /^info$/`, `.level ~ /^info$/`, `.msg ?`}, false}, // c matches the last one
+		{false, []string{`.msg ~ /^d$/`}, true},
+		{false, []string{`(`}, true},
+	} {
+		ft := new(fakeT)
+		if test.ordered {
+			rec.ExpectOrdered(ft, test.queries...)
+		} else {
+			rec.Expect(ft, test.queries...)
+		}
+		if fail := len(ft.failures) > 0; fail != test.fail {
+			t.Errorf("%v (ordered %v): got failure %v want %v: %v", test.queries, test.ordered, fail, test.fail, ft.failures)
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "testdata", "golden.log")
+	logger, rec := New()
+	logger.Info(*logfmt.S("msg", "started").S("time", "now"))
+	logger.Info(*logfmt.S("msg", "stopped").S("time", "later"))
+
+	*Update = true
+	rec.Golden(t, name, "time")
+	*Update = false
+	if data, _ := os.ReadFile(name); string(data) != "msg=started level=info\nmsg=stopped level=info\n" {
+		t.Errorf("unexpected golden file %q", data)
+	}
+	rec.Golden(t, name, "time")
+
+	logger.Info(*logfmt.S("msg", "again"))
+	ft := new(fakeT)
+	rec.Golden(ft, name, "time")
+	if len(ft.failures) != 1 || !strings.Contains(ft.failures[0], "+ msg=again level=info") {
+		t.Errorf("unexpected failures %q", ft.failures)
+	}
+}
@@ -2,46 +2,397 @@ package logfmt
 
 import (
 	"bufio"
+	"errors"
 	"io"
 	"os"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 // New creates a new Logger.
 // Out is the destination to write logs to.
-func New(out io.Writer) *Logger { return &Logger{out: bufio.NewWriter(out)} }
+func New(out io.Writer) *Logger {
+	return &Logger{
+		lock:     new(sync.Mutex),
+		out:      bufio.NewWriter(out),
+		level:    new(int32),
+		sink:     &sink{w: out},
+		sampler:  new(atomic.Value),
+		redactor: new(atomic.Pointer[Redactor]),
+		filter:   new(atomic.Pointer[Filter]),
+		encoded:  new(atomic.Pointer[boundLine]),
+	}
+}
 
-//Default is the default Logger implementation
+//Default is the default Logger implementation, do not Close it
 var Default = New(os.Stderr)
 
 //Logger is a basic object that logs records into an output io.Writer
 //
+// Records logged at a Level lower than the Logger's Level are dropped.
+//
+// Loggers are safe for concurrent use, several Loggers can write at the same time.
+//
+// Write errors do not stop the Logger: the line is lost, and the error is
+// reported by Err, and to the error handler if any.
 type Logger struct {
-	lock sync.Mutex // to force one log at a time
-	out  *bufio.Writer
+	lock    *sync.Mutex // to force one log at a time, shared with child loggers
+	out     *bufio.Writer
+	level   *int32   // the minimum Level, accessed atomically
+	bound   Fields   // attributes bound to every record
+	order   KeyOrder // the attributes order
+	console *Console // the line format, logfmt if nil
+	async   *queue   // the queue of lines to write, nil for a synchronous Logger
+	sink    *sink    // the output and its errors, shared with child loggers
+
+	sampler  *atomic.Value              // holds a samplerBox, shared with child loggers
+	redactor *atomic.Pointer[Redactor]  // shared with child loggers
+	filter   *atomic.Pointer[Filter]    // shared with child loggers
+	encoded  *atomic.Pointer[boundLine] // the bound attributes, ready to write, see With
+	tee      []*Logger                  // the Loggers to write to, instead of out
+}
+
+// sink is the output of a Logger
+type sink struct {
+	w       io.Writer
+	lock    sync.Mutex // guards the fields below
+	err     error      // the first write error
+	handler func(error)
+	closed  bool
+}
+
+// fail reports a write error
+func (s *sink) fail(err error) {
+	s.lock.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	handler := s.handler
+	s.lock.Unlock()
+	if handler != nil {
+		handler(err)
+	}
+}
+
+// close the writer, once, if it is an io.Closer
+func (s *sink) close() error {
+	s.lock.Lock()
+	closed := s.closed
+	s.closed = true
+	s.lock.Unlock()
+	if c, ok := s.w.(io.Closer); ok && !closed {
+		return c.Close()
+	}
+	return nil
+}
+
+// With returns a child Logger, sharing this Logger's output, lock and level.
+//
+// Every record logged by the child Logger is merged with the attributes of
+// 'rec', bound once for all. The record's own attributes override the bound
+// ones. Bound attributes are redacted and sorted once, on the first line, and
+// again only if the Redactor or the order changes.
+func (l *Logger) With(rec Record) *Logger {
+	child := *l
+	child.encoded = new(atomic.Pointer[boundLine])
+	child.bound = make(Fields, 0, len(l.bound)+len(rec))
+	for _, p := range l.bound {
+		if _, exists := rec[p.Key]; !exists {
+			child.bound = append(child.bound, p)
+		}
+	}
+	for _, p := range rec.Ordered(Significance) {
+		if p.Val != nil { // copy the value, the record might change later on
+			x := *p.Val
+			p.Val = &x
+		}
+		child.bound = append(child.bound, p)
+	}
+	return &child
+}
+
+// boundLine is the bound attributes, redacted, and sorted in the Logger order
+type boundLine struct {
+	redactor *Redactor // the Redactor they were redacted with
+	fields   Fields    // in the bound order
+	sorted   *fastKeySorter
+}
+
+// boundLine returns the bound attributes redacted by 'r', computed once. The lock must be held.
+func (l *Logger) boundLine(r *Redactor) *boundLine {
+	if b := l.encoded.Load(); b != nil && b.redactor == r {
+		return b
+	}
+	b := &boundLine{redactor: r, fields: r.Fields(l.bound), sorted: &fastKeySorter{less: l.order}}
+	for _, p := range b.fields {
+		b.sorted.add(p.Key, p.Val)
+	}
+	sort.Sort(b.sorted)
+	l.encoded.Store(b)
+	return b
+}
+
+// merge adds the sorted attributes of 'b' to 'dst', that 's' does not override
+// (they are in 'rec', or are the LevelKey of a leveled record), keeping 'dst'
+// sorted.
+func (b *boundLine) merge(dst, s *fastKeySorter, level *string, rec Record) {
+	i := 0
+	for j, key := range b.sorted.keys {
+		if _, exists := rec[key]; exists || level != nil && key == LevelKey {
+			continue
+		}
+		for ; i < len(s.keys) && s.before(s.keys[i], key); i++ {
+			dst.add(s.keys[i], s.vals[i])
+		}
+		dst.add(key, b.sorted.vals[j])
+	}
+	for ; i < len(s.keys); i++ {
+		dst.add(s.keys[i], s.vals[i])
+	}
 }
 
 //Log a Record to the Logger Output
-func (l *Logger) Log(rec Record) {
-	// acquire the lock to make sure nobody writes at the same time
+func (l *Logger) Log(rec Record) { l.write(nil, rec) }
+
+//LogFields logs Fields to the Logger Output, after the bound attributes, in the Logger's order
+func (l *Logger) LogFields(f Fields) {
+	if filter := l.filter.Load(); filter != nil && !(*filter)(withBound(l.bound, f).Record()) {
+		return
+	}
+	if l.Sampler() != nil && !l.sample(f.Record()) {
+		return
+	}
+	r := l.redactor.Load()
+	if r != nil {
+		f = r.Fields(f)
+	}
+	if l.tee != nil {
+		for _, t := range l.tee {
+			t.LogFields(withBound(r.Fields(l.bound), f))
+		}
+		return
+	}
+	if l.async != nil {
+		order, console, bound := l.format(r)
+		line := getLine()
+		console.fieldsTo(line, withBound(bound.fields, f).Ordered(order))
+		line.WriteRune('\n')
+		l.async.push(line)
+		return
+	}
 	l.lock.Lock()
-	// write the log
+	l.console.fieldsTo(l.out, withBound(l.boundLine(r).fields, f).Ordered(l.order))
+	l.out.WriteRune('\n')
+	err := l.flush()
+	l.lock.Unlock()
+	if err != nil {
+		l.sink.fail(err)
+	}
+}
+
+// withBound returns 'f' after the 'bound' attributes it does not override
+func withBound(bound, f Fields) Fields {
+	if len(bound) == 0 {
+		return f
+	}
+	all := make(Fields, 0, len(bound)+len(f))
+	for _, p := range bound {
+		if _, exists := f.Get(p.Key); !exists {
+			all = append(all, p)
+		}
+	}
+	return append(all, f...)
+}
+
+// flush the line written to out, in case of error the line is discarded. The lock must be held.
+func (l *Logger) flush() error {
+	err := l.out.Flush()
+	if err != nil {
+		l.out.Reset(l.sink.w) // clear the error, to write the next lines
+	}
+	return err
+}
+
+// Err returns the first error met while writing a line, nil if there is none.
+func (l *Logger) Err() error {
+	l.sink.lock.Lock()
+	defer l.sink.lock.Unlock()
+	return l.sink.err
+}
 
-	// not thread safe at all, it reuses a shared buffer (keyvals)
-	// but it's ok, when doing Log() since Log is protected against
-	keyvals.keys = keyvals.keys[0:0]
-	keyvals.vals = keyvals.vals[0:0]
-	logTo(l.out, rec, keyvals)
+// SetErrorHandler sets a function called on every write error, nil to remove it.
+//
+// It must not log into the Logger, or any of its child loggers.
+func (l *Logger) SetErrorHandler(handler func(err error)) {
+	l.sink.lock.Lock()
+	l.sink.handler = handler
+	l.sink.lock.Unlock()
+}
+
+// Flush waits for all the records logged so far to be written and flushed.
+//
+// A synchronous Logger flushes every record, Flush does nothing.
+func (l *Logger) Flush() {
+	for _, t := range l.tee {
+		t.Flush()
+	}
+	if l.async != nil {
+		l.async.flush()
+	}
+}
+
+// Close flushes the records logged so far, stops the background goroutine
+// of an asynchronous Logger, and closes the output if it is an io.Closer.
+// Records logged afterwards are discarded by an asynchronous Logger, and
+// fail with the output error otherwise.
+//
+// Child loggers share the output: it is closed once.
+//
+// It returns the output Close error, or else the first write error. A Tee
+// Logger closes all its Loggers.
+func (l *Logger) Close() error {
+	if s := l.Sampler(); s != nil {
+		for _, sum := range s.Summaries() {
+			l.emit(LevelWarn.value(), sum)
+		}
+	}
+	if l.tee != nil {
+		var errs []error
+		for _, t := range l.tee {
+			errs = append(errs, t.Close())
+		}
+		return errors.Join(errs...)
+	}
+	if l.async != nil {
+		l.async.close()
+	}
+	if err := l.sink.close(); err != nil {
+		return err
+	}
+	return l.Err()
+}
+
+// SetOrder sets the order of the attributes in each line, see KeyOrder.
+//
+// Child Loggers created afterwards inherit it.
+func (l *Logger) SetOrder(order KeyOrder) {
+	l.lock.Lock()
+	l.order = order
+	l.encoded.Store(nil) // sort the bound attributes again
+	l.lock.Unlock()
+}
+
+// format returns the attributes order, the Console format, and the bound attributes redacted by 'r'
+func (l *Logger) format(r *Redactor) (KeyOrder, *Console, *boundLine) {
+	l.lock.Lock()
+	order, console, bound := l.order, l.console, l.boundLine(r)
+	l.lock.Unlock()
+	return order, console, bound
+}
+
+// SetRedactor sets the Redactor of the values to write, nil to write them as is.
+//
+// It is shared with the child Loggers, it is safe to call it at any time.
+func (l *Logger) SetRedactor(r *Redactor) { l.redactor.Store(r) }
+
+// Level returns the minimum Level of the records to write.
+func (l *Logger) Level() Level { return Level(atomic.LoadInt32(l.level)) }
+
+// SetLevel sets the minimum Level of the records to write, it is safe to call it at any time.
+func (l *Logger) SetLevel(level Level) { atomic.StoreInt32(l.level, int32(level)) }
+
+// Enabled returns true if records at 'level' are written.
+func (l *Logger) Enabled(level Level) bool { return level >= l.Level() }
+
+// Debug logs a Record at debug level
+func (l *Logger) Debug(rec Record) { l.LogLevel(LevelDebug, rec) }
+
+// Info logs a Record at info level
+func (l *Logger) Info(rec Record) { l.LogLevel(LevelInfo, rec) }
+
+// Warn logs a Record at warn level
+func (l *Logger) Warn(rec Record) { l.LogLevel(LevelWarn, rec) }
+
+// Error logs a Record at error level
+func (l *Logger) Error(rec Record) { l.LogLevel(LevelError, rec) }
+
+// LogLevel logs a Record at 'level', with the LevelKey attribute set to 'level'.
+//
+// The Record is dropped, before being formatted, if 'level' is not enabled.
+func (l *Logger) LogLevel(level Level, rec Record) {
+	if !l.Enabled(level) {
+		return
+	}
+	l.write(level.value(), rec)
+}
+
+// write a Record, unless the Filter or the Sampler suppresses it
+func (l *Logger) write(level *string, rec Record) {
+	if filter := l.filter.Load(); filter != nil && !(*filter)(l.merge(level, rec)) {
+		return
+	}
+	if l.sample(rec) {
+		l.emit(level, rec)
+	}
+}
+
+// emit writes a Record merged with the bound attributes, 'level' is the
+// LevelKey value, nil if the record is not leveled.
+func (l *Logger) emit(level *string, rec Record) {
+	if l.tee != nil {
+		l.fanOut(level, rec)
+		return
+	}
+	// a sorter from the pool, so that several loggers can write at the same time
+	sorter := getSorter()
+	if level != nil {
+		sorter.add(LevelKey, level)
+	}
+	r := l.redactor.Load() // nil redacts nothing
+	for k, v := range rec {
+		if level == nil || k != LevelKey {
+			sorter.add(k, r.Value(k, v))
+		}
+	}
+
+	if l.async != nil {
+		// format the line now, the writer goroutine only copies it to the output
+		order, console, bound := l.format(r)
+		line := getLine()
+		sorter.less = order
+		sort.Sort(sorter)
+		sorter = mergeBound(sorter, bound, level, rec)
+		console.writeTo(line, sorter)
+		line.WriteRune('\n')
+		putSorter(sorter)
+		l.async.push(line)
+		return
+	}
+
+	// acquire the lock to make sure nobody writes at the same time
+	l.lock.Lock()
+	sorter.less = l.order
+	sort.Sort(sorter)
+	sorter = mergeBound(sorter, l.boundLine(r), level, rec)
+	l.console.writeTo(l.out, sorter)
 	l.out.WriteRune('\n')
-	l.out.Flush()
+	err := l.flush()
 	l.lock.Unlock() // we don't use defer, it takes a few extra seconds
+	putSorter(sorter)
+	if err != nil {
+		l.sink.fail(err)
+	}
 }
 
-// a local buffer of keyvals
-var (
-	bufSize int64 = 1024
-	keyvals       = &fastKeySorter{
-		keys: make([]string, 0, bufSize),
-		vals: make([]*string, 0, bufSize),
+// mergeBound returns a sorter with the attributes of 's' and 'bound', and puts 's' back in the pool.
+func mergeBound(s *fastKeySorter, bound *boundLine, level *string, rec Record) *fastKeySorter {
+	if len(bound.fields) == 0 {
+		return s
 	}
-)
+	merged := getSorter()
+	merged.less = s.less
+	bound.merge(merged, s, level, rec)
+	putSorter(s)
+	return merged
+}
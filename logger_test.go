@@ -2,7 +2,12 @@ package logfmt
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -33,3 +38,252 @@ func BenchmarkDefaultLogger(b *testing.B) {
 		log.Printf("username=%q\n", "eric")
 	}
 }
+
+func ExampleLogger_SetLevel() {
+	Default = New(os.Stdout)
+	Default.SetLevel(LevelInfo)
+
+	S("msg", "dropped").Debug()
+	S("msg", "started").D("port", 8080).Info()
+	Default.Error(*S("msg", "failed").S("level", "overridden"))
+	//Output:
+	// msg=started port=8080 level=info
+	// msg=failed level=error
+}
+
+func TestLevel(t *testing.T) {
+	for _, l := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		p, err := ParseLevel(strings.ToUpper(l.String()))
+		if err != nil || p != l {
+			t.Errorf("cannot parse level %v: %v %v", l, p, err)
+		}
+	}
+	if _, err := ParseLevel("fatal"); err == nil {
+		t.Errorf("unknown level should not be parsed")
+	}
+
+	var buf bytes.Buffer
+	l := New(&buf)
+	l.SetLevel(LevelWarn)
+	l.Info(*K("info"))
+	l.Warn(*K("warn"))
+	l.Log(*K("log")) // not leveled, always logged
+	if buf.String() != "warn level=warn\nlog\n" {
+		t.Errorf("invalid leveled output %q", buf.String())
+	}
+}
+
+func ExampleLogger_With() {
+	Default = New(os.Stdout)
+	req := Default.With(*S("service", "api").D("request_id", 42))
+
+	req.Info(*S("msg", "started"))
+	req.Log(*S("msg", "overridden").D("request_id", 43))
+	req.With(*S("user", "eric")).LogFields(Fields{{Key: "msg", Val: new(string)}})
+	//Output:
+	// msg=started level=info service=api request_id=42
+	// msg=overridden service=api request_id=43
+	// service=api request_id=42 user=eric msg=
+}
+
+func TestLoggerWith(t *testing.T) {
+	var buf bytes.Buffer
+	parent := New(&buf)
+	rec := S("user", "eric")
+	child := parent.With(*rec)
+	rec.S("user", "changed") // bound attributes are not changed
+
+	parent.SetLevel(LevelWarn) // the level is shared
+	child.Info(*K("dropped"))
+	child.Warn(*K("kept"))
+	if buf.String() != "kept user=eric level=warn\n" {
+		t.Errorf("invalid child output %q", buf.String())
+	}
+
+	// the bound attributes are encoded once, until the order or the Redactor change
+	buf.Reset()
+	child.Warn(*S("b", "1"))
+	child.SetOrder(Alphabetical)
+	child.Warn(*S("b", "1"))
+	child.SetRedactor(&Redactor{Keys: []string{"user"}})
+	child.Warn(*S("b", "1"))
+	child.LogFields(Fields{{Key: "b", Val: (*rec)["user"]}})
+	want := "b=1 user=eric level=warn\n" +
+		"b=1 level=warn user=eric\n" +
+		"b=1 level=warn user=***\n" +
+		"b=changed user=***\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func BenchmarkLoggerWith(b *testing.B) {
+	// Benchmark a small log with three attribute, and two bound ones
+	for _, bench := range []struct {
+		name     string
+		redactor *Redactor
+	}{
+		{"plain", nil},
+		{"hashed", &Redactor{Keys: []string{"service", "request_id"}, Hash: true}}, // the bound values are sensitive
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			var buf bytes.Buffer
+			l := New(&buf).With(*S("service", "api").D("request_id", 42))
+			l.SetRedactor(bench.redactor)
+			for i := 0; i < b.N; i++ {
+				r := Rec()
+				r.D("at", i)
+				r.Q("username", "eric")
+				r.K("debug")
+				l.Log(*r)
+			}
+		})
+	}
+}
+
+func ExampleLogger_SetOrder() {
+	Default = New(os.Stdout)
+	rec := *S("msg", "started").S("level", "info").S("time", "12:00").D("port", 8080).K("debug")
+
+	Default.Log(rec)
+	Default.SetOrder(Alphabetical)
+	Default.Log(rec)
+	Default.SetOrder(Priority(nil, "time", "level", "msg"))
+	Default.Log(rec)
+	Default.SetOrder(Insertion)
+	Default.LogFields(Fields{{"b", nil}, {"a", nil}})
+	//Output:
+	// msg=started port=8080 time=12:00 debug level=info
+	// debug level=info msg=started port=8080 time=12:00
+	// time=12:00 level=info msg=started port=8080 debug
+	// b a
+}
+
+func TestConcurrentLoggers(t *testing.T) {
+	// run with -race: several loggers, and Record.String, at the same time
+	var b1, b2 bytes.Buffer
+	l1, l2 := New(&b1), New(&b2).With(*S("service", "api"))
+	l2.SetOrder(Alphabetical)
+
+	const N, M = 8, 100
+	var wg sync.WaitGroup
+	for g := 0; g < N; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < M; i++ {
+				rec := *D("goroutine", g).D("i", i).Q("user", "eric")
+				l1.Log(rec)
+				l2.Info(rec)
+				if s := rec.String(); s != fmt.Sprintf(`i=%d user="eric" goroutine=%d`, i, g) {
+					t.Errorf("invalid String %q", s)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	check := func(buf *bytes.Buffer, pattern string) {
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != N*M {
+			t.Fatalf("invalid number of lines %d instead of %d", len(lines), N*M)
+		}
+		for _, line := range lines {
+			var g, i int
+			if _, err := fmt.Sscanf(line, pattern, &g, &i); err != nil {
+				t.Errorf("invalid line %q: %v", line, err)
+			}
+		}
+	}
+	check(&b1, `i=%d user="eric" goroutine=%d`)
+	check(&b2, `goroutine=%d i=%d level=info service=api user="eric"`)
+}
+
+func BenchmarkLoggerParallel(b *testing.B) {
+	// Benchmark the same small log, from several goroutines, with two loggers
+	var b1, b2 bytes.Buffer
+	l1, l2 := New(&b1), New(&b2)
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			r := Rec()
+			r.D("at", i)
+			r.Q("username", "eric")
+			r.K("debug")
+			l1.Log(*r)
+			l2.Log(*r)
+		}
+	})
+}
+
+// failWriter fails every write after the first 'ok' ones
+type failWriter struct {
+	ok     int
+	lines  []string
+	closed int
+}
+
+var errDiskFull = errors.New("disk full")
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if w.ok == 0 {
+		return 0, errDiskFull
+	}
+	w.ok--
+	w.lines = append(w.lines, string(p))
+	return len(p), nil
+}
+
+func (w *failWriter) Close() error { w.closed++; return nil }
+
+func TestWriteError(t *testing.T) {
+	w := &failWriter{ok: 1}
+	logger := New(w)
+	var handled []error
+	logger.SetErrorHandler(func(err error) { handled = append(handled, err) })
+
+	logger.Log(*D("i", 1))
+	if err := logger.Err(); err != nil {
+		t.Errorf("Err() = %v want nil", err)
+	}
+	logger.Log(*D("i", 2))
+	logger.With(*K("child")).Log(*D("i", 3))
+	if err := logger.Err(); err != errDiskFull {
+		t.Errorf("Err() = %v want %v", err, errDiskFull)
+	}
+	if len(handled) != 2 {
+		t.Errorf("error handler called %d times, want 2", len(handled))
+	}
+
+	// the Logger goes on writing, once the writer recovers
+	w.ok = 1
+	logger.Log(*D("i", 4))
+	if got := strings.Join(w.lines, ""); got != "i=1\ni=4\n" {
+		t.Errorf("got %q want %q", got, "i=1\ni=4\n")
+	}
+
+	if err := logger.Close(); err != errDiskFull {
+		t.Errorf("Close() = %v want %v", err, errDiskFull)
+	}
+	logger.With(*K("child")).Close()
+	if w.closed != 1 {
+		t.Errorf("writer closed %d times, want 1", w.closed)
+	}
+}
+
+func TestAsyncWriteError(t *testing.T) {
+	w := &failWriter{}
+	logger := NewAsync(w, 8, OverflowBlock)
+	logger.Log(*D("i", 1))
+	logger.Flush()
+	if err := logger.Err(); err != errDiskFull {
+		t.Errorf("Err() = %v want %v", err, errDiskFull)
+	}
+	w.ok = 1
+	logger.Log(*D("i", 2))
+	if err := logger.Close(); err != errDiskFull {
+		t.Errorf("Close() = %v want %v", err, errDiskFull)
+	}
+	if got := strings.Join(w.lines, ""); got != "i=2\n" || w.closed != 1 {
+		t.Errorf("got %q, closed %d times, want %q closed once", got, w.closed, "i=2\n")
+	}
+}
@@ -0,0 +1,33 @@
+package logreader
+
+import "github.com/etnz/logfmt"
+
+// Decoder reads records from a Reader, and stores them into structs, see logfmt.Unmarshal.
+//
+//    dec := NewDecoder(New(os.Stdin))
+//    for dec.HasNext() {
+//        var req Request
+//        if err := dec.Decode(&req); err != nil {
+//            ...
+//        }
+//    }
+type Decoder struct {
+	r Reader
+}
+
+// NewDecoder instanciate a new Decoder reading from 'r'
+func NewDecoder(r Reader) *Decoder { return &Decoder{r: r} }
+
+// HasNext return true has long as there are records to decode
+func (d *Decoder) HasNext() bool { return d.r.HasNext() }
+
+// Decode reads the next record, and stores it into the struct pointed to by 'v'.
+//
+// Reading errors are returned as is, conversion errors as *logfmt.UnmarshalError.
+func (d *Decoder) Decode(v interface{}) error {
+	rec, err := d.r.Next()
+	if err != nil {
+		return err
+	}
+	return logfmt.Unmarshal(rec, v)
+}
@@ -0,0 +1,29 @@
+package logreader
+
+import (
+	"fmt"
+	"strings"
+)
+
+func ExampleDecoder() {
+	type request struct {
+		Method string `logfmt:"method"`
+		Path   string `logfmt:"path"`
+		Status int    `logfmt:"status"`
+	}
+	src := `method=GET path="/" status=200
+method=POST path="/login" status=403`
+
+	dec := NewDecoder(New(strings.NewReader(src)))
+	for dec.HasNext() {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("%+v\n", req)
+	}
+	//Output:
+	// {Method:GET Path:/ Status:200}
+	// {Method:POST Path:/login Status:403}
+}
@@ -1,18 +1,16 @@
 package logreader
 
 import (
-	"bufio"
-	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/etnz/logfmt"
 )
 
 const (
-	//end of file
-	eof = '\x00'
 	//end of line
 	eol = '\n'
 )
@@ -20,142 +18,136 @@ const (
 // ErrUnterminatedQuote is an error returned when the end of file is reached before the end of quoted string.
 var ErrUnterminatedQuote = errors.New("Error unterminated quoted string")
 
-type scanner struct {
-	*bufio.Reader
-	err error
-	eof bool
-}
+// Errors only returned by strict Readers and Tokenizers
+var (
+	// ErrEmptyKey is returned when a value has no key, like in '=value'.
+	ErrEmptyKey = errors.New("Error empty key")
 
-//Reader reads from any source successives records
-type Reader interface {
-	HasNext() bool
-	Next() (rec logfmt.Record, err error)
-}
+	// ErrUnexpectedQuote is returned when a quote is found outside of a value, like in 'key"'.
+	ErrUnexpectedQuote = errors.New("Error unexpected quote")
 
-// New instanciate a new Reader
-func New(r io.Reader) Reader { return newScanner(r) }
+	// ErrInvalidEscape is returned when a quoted string contains an invalid escape sequence.
+	ErrInvalidEscape = errors.New("Error invalid escape sequence")
 
-// Parse a single record as string.
-func Parse(src string) (rec logfmt.Record, err error) { return newScannerS(src).Next() }
+	// ErrGarbage is returned when pairs are not separated by spaces, or separated by control characters.
+	ErrGarbage = errors.New("Error garbage between pairs")
+)
 
-func newScannerS(str string) *scanner { return newScanner(strings.NewReader(str)) }
+// snippetSize is the maximum number of bytes of the raw line kept in a ParseError.
+const snippetSize = 64
 
-func newScanner(r io.Reader) *scanner {
-	return &scanner{
-		Reader: bufio.NewReader(r),
-	}
+// ParseError is returned by Reader.Next (and Tokenizer.Err) when a line cannot be parsed.
+//
+// It locates the error in the source, and keeps a snippet of the offending line.
+type ParseError struct {
+	Line   int    // line number, starting at 1
+	Offset int64  // byte offset of the error in the source
+	Column int    // byte column of the error in the line, starting at 1
+	Text   string // snippet of the raw line
+	Err    error  // the actual error, e.g. ErrUnterminatedQuote
 }
 
-//the rules for scanning
-func isIdentifier(r rune) bool { return r != eof && r > ' ' && r != '"' && r != '=' }
-func isGarbage(r rune) bool    { return r != eof && r != eol && r <= ' ' }
-func isString(r rune) bool     { return r != eof && r != '"' && r != '\\' }
-
-// HasNext return true has long as the scanned has not found the end of file
-func (s *scanner) HasNext() bool { return !s.eof && s.err == nil }
-
-// Read read a single rune from the src
-func (s *scanner) Read() (r rune) {
-	r, _, s.err = s.ReadRune()
-	if r == eof {
-		s.eof = true
-		s.err = nil
-	}
-	return
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v: %q", e.Line, e.Column, e.Err, e.Text)
 }
 
-// Unread the previous rune from the src
-func (s *scanner) Unread() {
-	s.UnreadRune()
+// Unwrap returns the actual error.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+//Reader reads from any source successives records
+type Reader interface {
+	HasNext() bool
+	Next() (rec logfmt.Record, err error)
 }
 
-// Next read runes until it has found a full Record, returns it.
+// FieldsReader is a Reader that can also read records as Fields.
 //
-// If the source has errors it returns it
-func (s *scanner) Next() (record logfmt.Record, err error) {
-	rec := logfmt.Rec()
-	for {
-
-		if r := s.Read(); r == eol || r == eof {
-			return *rec, s.err
-		}
-		s.Unread()
-
-		key, val := s.Pair()
-		(*rec)[key] = val
-	}
+// NextFields reads the next record as Fields, keeping attributes in order,
+// duplicated keys included. Line returns the raw line of the last record
+// read, it is only valid until the next call to HasNext.
+type FieldsReader interface {
+	Reader
+	NextFields() (fields logfmt.Fields, err error)
+	Line() []byte
 }
 
-// Pair scan for a couple key(=value)?
-func (s *scanner) Pair() (key string, value *string) {
+// New instanciate a new Reader
+//
+// The Reader is lenient: it does its best to read any line.
+func New(r io.Reader) FieldsReader { return newReader(NewTokenizer(r)) }
 
-	s.Garbage() //consumer the possible garbage
-	key = s.Identifier()
+// NewStrict instanciate a new strict Reader
+//
+// Lines that do not conform to logfmt are reported as *ParseError, and skipped.
+func NewStrict(r io.Reader) FieldsReader { return newReader(NewStrictTokenizer(r)) }
 
-	s.Garbage() //consumer the possible garbage
+// Parse a single record as string.
+func Parse(src string) (rec logfmt.Record, err error) { return newReaderS(src).Next() }
 
-	if r := s.Read(); r == '=' {
-		// separator there might be a value
-		s.Garbage() //consumer extra space after =
-		//then attempt to read the value (might not exist)
-		v := s.Value()
-		value = &v
-		s.Garbage() //consumer extra space
-		return
-	}
-	s.Unread()
-	return
+// ParseStrict parses a single record as string, using a strict Reader.
+func ParseStrict(src string) (rec logfmt.Record, err error) {
+	r := newReaderS(src)
+	r.strict = true
+	return r.Next()
 }
 
-//Value scan for a valid value
-func (s *scanner) Value() (value string) {
+// ParseFields parses a single record as string, into Fields.
+func ParseFields(src string) (fields logfmt.Fields, err error) { return newReaderS(src).NextFields() }
 
-	if r := s.Read(); r == '"' { //it will be a string
-		value = s.Str()
-		end := s.Read() //read the " or the eof one more time
-		if end != '"' {
-			s.err = ErrUnterminatedQuote
-		}
-		return
-	}
-	s.Unread()
+// reader builds Records from a Tokenizer
+type reader struct {
+	*Tokenizer
+	scanned bool // true if the Tokenizer has already scanned the next line
+	more    bool // the last Scan result
+}
 
-	return s.Identifier()
+func newReader(t *Tokenizer) *reader { return &reader{Tokenizer: t} }
 
-}
+func newReaderS(str string) *reader { return newReader(NewTokenizer(strings.NewReader(str))) }
 
-// Garbage consumes as much as possible "garbage" char (separators)
-func (s *scanner) Garbage() {
-	for r := s.Read(); isGarbage(r); r = s.Read() {
+// HasNext return true has long as there are lines to read
+func (r *reader) HasNext() bool {
+	if !r.scanned {
+		r.more, r.scanned = r.Scan(), true
 	}
-	s.Unread()
+	return r.more
 }
 
-// Identifier scan for an identifier
-func (s *scanner) Identifier() (identifier string) {
-
-	var buf bytes.Buffer
-	for r := s.Read(); isIdentifier(r); r = s.Read() {
-		buf.WriteRune(r)
-	}
-	s.Unread()
-	return buf.String()
+// Next reads the next line as a Record, returns it.
+//
+// If the source has errors it returns it, parsing errors are returned as *ParseError
+func (r *reader) Next() (record logfmt.Record, err error) {
+	rec := logfmt.Rec()
+	err = r.read(func(key string, val *string) { (*rec)[key] = val })
+	return *rec, err
 }
 
-// Str scan for a quoted string, and escape any character right after '\'
-func (s *scanner) Str() (str string) {
+// NextFields reads the next line as Fields, returns it.
+//
+// If the source has errors it returns it, parsing errors are returned as *ParseError
+func (r *reader) NextFields() (fields logfmt.Fields, err error) {
+	err = r.read(func(key string, val *string) { fields.Add(key, val) })
+	return
+}
 
-	var buf bytes.Buffer
+// read the next line, calling 'add' for each attribute
+func (r *reader) read(add func(key string, val *string)) error {
+	if !r.HasNext() {
+		return r.Err()
+	}
+	r.scanned = false
 
-	for r := s.Read(); isString(r) || r == '\\'; r = s.Read() {
-		if r == '\\' {
-			r = s.Read()
-			// write the next after \ This is more general than just the usual definition, as a side effect, '\' followed by a line break insert the line break
-			// to implement a strict version we "could" test r right now, it must be '"' or '\\'
+	for key, value, hasValue := r.Tokenizer.Next(); key != nil; key, value, hasValue = r.Tokenizer.Next() {
+		if !hasValue {
+			add(string(key), nil)
+			continue
 		}
-		buf.WriteRune(r)
+		// quoted strings are stored back the way logfmt.Record.Q does.
+		v := string(value)
+		if r.Quoted() {
+			v = strconv.Quote(v)
+		}
+		add(string(key), &v)
 	}
-	s.Unread()
-
-	return buf.String()
+	return r.Err()
 }
@@ -1,8 +1,14 @@
 package logreader
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/etnz/logfmt"
 )
 
 func ExampleReader() {
@@ -18,7 +24,198 @@ func ExampleReader() {
 		fmt.Println(rec)
 	}
 	//Output:
-	// at=1234578 path=/login user=foo@bar.com debug
-	// at=1234589 path=/login user=bar@bar.com debug
-	// at=1234599 path=/login user=baz@bar.com debug
+	// at=1234578 path=/login user="foo@bar.com" debug
+	// at=1234589 path=/login user="bar@bar.com" debug
+	// at=1234599 path=/login user="baz@bar.com" debug
+}
+
+func TestParseError(t *testing.T) {
+
+	src := "a=1 b=2\nc=3 d=\"unterminated\n"
+
+	r := New(strings.NewReader(src))
+	if _, err := r.Next(); err != nil {
+		t.Fatalf("unexpected error on first line: %v", err)
+	}
+	_, err := r.Next()
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expecting a *ParseError got %T: %v", err, err)
+	}
+	if perr.Line != 2 || perr.Column != 7 || perr.Offset != 14 {
+		t.Errorf("invalid position line=%d column=%d offset=%d", perr.Line, perr.Column, perr.Offset)
+	}
+	if perr.Text != `c=3 d="unterminated` {
+		t.Errorf("invalid snippet %q", perr.Text)
+	}
+	if !errors.Is(err, ErrUnterminatedQuote) {
+		t.Errorf("expecting ErrUnterminatedQuote got %v", perr.Err)
+	}
+	if r.HasNext() {
+		t.Errorf("reader should stop after an unterminated quote")
+	}
+}
+
+func TestStrict(t *testing.T) {
+
+	cases := []struct {
+		src    string
+		err    error
+		column int
+	}{
+		{`a=1 b="two" c`, nil, 0},
+		{`a=1   `, nil, 0},
+		{`a="é\t\"\\"`, nil, 0},
+		{`=v`, ErrEmptyKey, 1},
+		{`a = b`, ErrEmptyKey, 3},
+		{`a=1 "foo" b=2`, ErrUnexpectedQuote, 5},
+		{`a=b"c"`, ErrUnexpectedQuote, 4},
+		{`a="x"y b`, ErrGarbage, 6},
+		{"a\x01b", ErrGarbage, 2},
+		{`a="x\q" b`, ErrInvalidEscape, 5},
+		{`a="x\u12" b`, ErrInvalidEscape, 5},
+		{`a="x`, ErrUnterminatedQuote, 3},
+	}
+	for _, c := range cases {
+		_, err := ParseStrict(c.src)
+		if !errors.Is(err, c.err) || (c.err == nil) != (err == nil) {
+			t.Errorf("ParseStrict(%q) returned %v instead of %v", c.src, err, c.err)
+			continue
+		}
+		if perr, ok := err.(*ParseError); ok && perr.Column != c.column {
+			t.Errorf("ParseStrict(%q) error at column %d instead of %d", c.src, perr.Column, c.column)
+		}
+		// lenient parsing never fails but for unterminated quotes
+		if _, err := Parse(c.src); err != nil && c.err != ErrUnterminatedQuote {
+			t.Errorf("Parse(%q) returned %v", c.src, err)
+		}
+	}
+}
+
+func TestStrictSkipLine(t *testing.T) {
+
+	for src, want := range map[string]string{
+		"a=1\n=2 b=3\nc=4\n":             "a=1,error,c=4",
+		"a=1\nb=\"oops\nc=3\nd=4\ne=5\n": "a=1,error,c=3,d=4,e=5", // unbalanced quote
+	} {
+		r := NewStrict(strings.NewReader(src))
+		var lines []string
+		for r.HasNext() {
+			rec, err := r.Next()
+			if err != nil {
+				lines = append(lines, "error")
+				continue
+			}
+			lines = append(lines, rec.String())
+		}
+		if got := strings.Join(lines, ","); got != want {
+			t.Errorf("invalid strict reading of %q: %q instead of %q", src, got, want)
+		}
+	}
+}
+
+func TestLenientSkipQuote(t *testing.T) {
+
+	far := strings.Repeat("b=2\n", maxContinuation/4+1) // the quote is closed too far away
+	for src, want := range map[string]string{
+		"a=1 msg=\"oops\n" + strings.Repeat("b=2\n", 5): "error:1" + strings.Repeat(",b=2", 5),
+		"a=\"multi\nline\"\nb=2\n":                      `a="multi\nline",b=2`,
+		"a=\"oops\n" + far + "c=\"x\"\n":                "error:1" + strings.Repeat(",b=2", maxContinuation/4+1) + `,c="x"`,
+	} {
+		r := New(strings.NewReader(src))
+		var lines []string
+		for r.HasNext() {
+			rec, err := r.Next()
+			var perr *ParseError
+			switch {
+			case errors.As(err, &perr):
+				lines = append(lines, fmt.Sprintf("error:%d", perr.Line))
+			case err != nil:
+				t.Fatal(err)
+			default:
+				lines = append(lines, rec.String())
+			}
+		}
+		if got := strings.Join(lines, ","); got != want {
+			t.Errorf("invalid lenient reading of %.40q: %.100q", src, got)
+		}
+	}
+}
+
+func TestUnquote(t *testing.T) {
+
+	rec, err := Parse(`a="é\n\t\x00\\\"" b="\q" c="multi
+line"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"a": `"é\n\t\x00\\\""`,
+		"b": `"q"`, // invalid escape, lenient: the escaped char is kept as is
+		"c": `"multi\nline"`,
+	} {
+		if got := *rec[key]; got != want {
+			t.Errorf("invalid %s value %s instead of %s", key, got, want)
+		}
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	f.Add("key", "value", "ident", 42, 3.14, true)
+	f.Add("k.x", "é\n\t\x00\\\"", `"quoted"`, -1, 1e-9, false)
+	f.Add("user", "two words", "a=b", 0, 0.0, false)
+	f.Add("user", "", "\"x\\q", 0, 0.0, false)
+
+	f.Fuzz(func(t *testing.T, key, q, s string, d int, g float64, b bool) {
+		if key == "" || strings.IndexFunc(key, func(r rune) bool { return r <= ' ' || r == '"' || r == '=' || r == utf8.RuneError }) >= 0 {
+			t.Skip("invalid key")
+		}
+		rec := logfmt.Rec().
+			Q(key, q).
+			S(key+".s", s).
+			V(key+".v", q).
+			D(key+".d", d).
+			G(key+".g", g).
+			T(key+".t", b).
+			K(key + ".k")
+
+		var buf bytes.Buffer
+		logfmt.New(&buf).Log(*rec)
+
+		got, err := Parse(buf.String())
+		if err != nil {
+			t.Fatalf("cannot parse %q: %v", buf.String(), err)
+		}
+		if !rec.Equal(got) {
+			t.Errorf("invalid round trip for %q: got %v", buf.String(), got)
+		}
+	})
+}
+
+func TestParseFields(t *testing.T) {
+
+	src := `z=1 debug a="two words" z=3`
+	fields, err := ParseFields(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fields.String(); got != src {
+		t.Errorf("invalid fields %q instead of %q", got, src)
+	}
+}
+
+func TestLine(t *testing.T) {
+
+	src := "a=\"caf\\u00e9\"  b  =  c d=\"\t\"\nnext=1\n"
+	r := New(strings.NewReader(src))
+	var lines []string
+	for r.HasNext() {
+		if _, err := r.NextFields(); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(r.Line()))
+	}
+	if got := strings.Join(lines, "\n") + "\n"; got != src {
+		t.Errorf("got raw lines %q want %q", got, src)
+	}
 }
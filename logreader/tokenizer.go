@@ -0,0 +1,350 @@
+package logreader
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Tokenizer reads a logfmt stream line by line, and splits each line into key/value tokens.
+//
+// It is the low level API Reader is built on. Tokens are slices of a line
+// buffer reused from one line to the next: scanning a stream does not allocate.
+//
+//    t := NewTokenizer(os.Stdin)
+//    for t.Scan() {
+//        for key, value, hasValue := t.Next(); key != nil; key, value, hasValue = t.Next() {
+//            ...
+//        }
+//        if err := t.Err(); err != nil {
+//            ...
+//        }
+//    }
+type Tokenizer struct {
+	src    *bufio.Reader
+	err    error // the source error
+	eof    bool
+	strict bool
+
+	line       []byte // raw bytes of the current line, and of the next ones when a quoted value spans several lines
+	lineOffset int64  // byte offset of the current line in the source
+	lineNo     int    // line number of the current line
+	pos        int    // position of the next token in line
+	next       int64  // byte offset of the next line to read
+	nextNo     int    // line number of the next line to read
+	pending    []byte // lines read ahead for an unterminated quoted value, to read again
+
+	quoted bool        // true if the last value was quoted
+	buf    []byte      // buffer for unquoted values
+	perr   *ParseError // the current line parsing error
+}
+
+// NewTokenizer instanciate a new lenient Tokenizer, it does its best to read any line.
+//
+// A quoted value can span several lines, up to 64KiB: an unterminated quote
+// fails its own line only, the lines after it are read as usual.
+func NewTokenizer(r io.Reader) *Tokenizer {
+	return &Tokenizer{
+		src:    bufio.NewReader(r),
+		nextNo: 1,
+	}
+}
+
+// maxContinuation is the maximum size of the lines a quoted value can span,
+// after its first line. Beyond that, or at the end of the source, the quote
+// is unterminated and the next lines are read as usual.
+const maxContinuation = 64 << 10
+
+// NewStrictTokenizer instanciate a new strict Tokenizer
+//
+// Lines that do not conform to logfmt are reported by Err, as a *ParseError.
+// A quoted value cannot span several lines.
+func NewStrictTokenizer(r io.Reader) *Tokenizer {
+	t := NewTokenizer(r)
+	t.strict = true
+	return t
+}
+
+//the rules for tokenizing
+func isIdentifier(c byte) bool { return c > ' ' && c != '"' && c != '=' }
+func isGarbage(c byte) bool    { return c != eol && c <= ' ' }
+func isSeparator(c byte) bool  { return c == ' ' || c == '\t' || c == '\r' } // the only garbage allowed in strict mode
+
+// Scan advances to the next line, it returns false at the end of the source.
+func (t *Tokenizer) Scan() bool {
+	t.line = t.line[:0]
+	t.pos = 0
+	t.perr = nil
+	t.lineOffset, t.lineNo = t.next, t.nextNo
+	return t.readLine()
+}
+
+// Next returns the next key/value pair of the current line.
+//
+// 'hasValue' is false for key only attributes. At the end of the line 'key' is nil.
+//
+// Key and value are only valid until the next call to Next or Scan. Quoted
+// values are unquoted, see Quoted.
+func (t *Tokenizer) Next() (key, value []byte, hasValue bool) {
+	for {
+		t.garbage()
+		if t.end() {
+			return nil, nil, false
+		}
+
+		start := t.pos
+		key = t.identifier()
+		if !t.strict {
+			t.garbage()
+		}
+		if !t.end() && t.line[t.pos] == '=' {
+			if t.strict && len(key) == 0 {
+				t.fail(start, ErrEmptyKey)
+				return nil, nil, false
+			}
+			// separator there might be a value
+			t.pos++
+			if !t.strict {
+				t.garbage()
+			}
+			value, hasValue = t.value(), true
+		}
+
+		// the pair must be followed by a separator
+		switch {
+		case t.perr != nil:
+			return nil, nil, false
+		case t.end() || isGarbage(t.line[t.pos]):
+			return
+		case !t.strict:
+			if len(key) == 0 && !hasValue {
+				t.pos++ // a stray quote cannot be read: skip it
+				continue
+			}
+			return
+		case t.line[t.pos] == '"':
+			t.fail(t.pos, ErrUnexpectedQuote)
+		default:
+			t.fail(t.pos, ErrGarbage)
+		}
+		return nil, nil, false
+	}
+}
+
+// Quoted returns true if the last value returned by Next was a quoted string.
+func (t *Tokenizer) Quoted() bool { return t.quoted }
+
+// Line returns the raw bytes of the current line, without the end of line.
+//
+// It is only valid until the next call to Scan.
+func (t *Tokenizer) Line() []byte { return bytes.TrimSuffix(t.line, []byte{eol}) }
+
+// Err returns the error found while reading the current line: either a *ParseError, or the source error.
+func (t *Tokenizer) Err() error {
+	if t.perr != nil {
+		return t.perr
+	}
+	return t.err
+}
+
+// readLine appends the next line of the source to the current line, it returns false if there is none.
+func (t *Tokenizer) readLine() bool {
+	n := len(t.line)
+	if len(t.pending) > 0 {
+		i := bytes.IndexByte(t.pending, eol) + 1
+		if i == 0 {
+			i = len(t.pending)
+		}
+		t.line, t.pending = append(t.line, t.pending[:i]...), t.pending[i:]
+		t.count(n)
+		return true
+	}
+	if t.eof || t.err != nil {
+		return false
+	}
+	for {
+		chunk, err := t.src.ReadSlice(eol)
+		t.line = append(t.line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue // the line is longer than the buffer
+		}
+		if err == io.EOF {
+			t.eof = true
+		} else if err != nil {
+			t.err = err
+		}
+		break
+	}
+	t.count(n)
+	return len(t.line) > n
+}
+
+// count the bytes of the current line read from 'n' on
+func (t *Tokenizer) count(n int) {
+	t.next += int64(len(t.line) - n)
+	if len(t.line) > n && t.line[len(t.line)-1] == eol {
+		t.nextNo++
+	}
+}
+
+// unread the lines after the first one of the current line, to read them again
+func (t *Tokenizer) unread() {
+	first := bytes.IndexByte(t.line, eol) + 1
+	if first == 0 || first == len(t.line) {
+		return
+	}
+	t.pending = append(append([]byte(nil), t.line[first:]...), t.pending...)
+	t.line = t.line[:first]
+	t.next, t.nextNo = t.lineOffset+int64(first), t.lineNo+1
+}
+
+// end returns true at the end of the current line
+func (t *Tokenizer) end() bool { return t.pos >= len(t.line) || t.line[t.pos] == eol }
+
+// garbage consumes as much as possible "garbage" char (separators)
+func (t *Tokenizer) garbage() {
+	for ; t.pos < len(t.line) && isGarbage(t.line[t.pos]); t.pos++ {
+		if t.strict && !isSeparator(t.line[t.pos]) {
+			t.fail(t.pos, ErrGarbage)
+			return
+		}
+	}
+}
+
+// identifier scan for an identifier
+func (t *Tokenizer) identifier() []byte {
+	start := t.pos
+	for t.pos < len(t.line) && isIdentifier(t.line[t.pos]) {
+		t.pos++
+	}
+	return t.line[start:t.pos]
+}
+
+// value scan for a valid value, either an identifier or a quoted string
+func (t *Tokenizer) value() []byte {
+	t.quoted = t.pos < len(t.line) && t.line[t.pos] == '"'
+	if !t.quoted {
+		return t.identifier()
+	}
+
+	start := t.pos
+	escaped := false
+	for t.pos++; ; {
+		if t.pos >= len(t.line) {
+			// the quoted string goes on, on the next line, within limits
+			if len(t.line)-bytes.IndexByte(t.line, eol) > maxContinuation || !t.readLine() {
+				t.unread()
+				t.fail(start, ErrUnterminatedQuote)
+				return nil
+			}
+			continue
+		}
+		switch t.line[t.pos] {
+		case '"':
+			raw := t.line[start+1 : t.pos]
+			t.pos++
+			if !escaped {
+				return raw
+			}
+			t.buf = appendUnquote(t.buf[:0], raw)
+			return t.buf
+
+		case eol:
+			if t.strict {
+				// a quoted value cannot span several lines: resume on the next one
+				t.fail(start, ErrUnterminatedQuote)
+				return nil
+			}
+			t.pos++
+
+		case '\\':
+			if t.strict && !isEscape(t.line[t.pos:]) {
+				t.fail(t.pos, ErrInvalidEscape)
+				return nil
+			}
+			escaped = true
+			t.pos += 2 // the next char is escaped, even a '"'
+
+		default:
+			t.pos++
+		}
+	}
+}
+
+// fail records 'err' found at 'pos' in the current line, and skips the rest of the line
+func (t *Tokenizer) fail(pos int, err error) {
+	t.perr = t.errorAt(pos, err)
+	t.pos = len(t.line)
+}
+
+// errorAt wraps 'err' found at 'pos' in the current line into a ParseError
+func (t *Tokenizer) errorAt(pos int, err error) *ParseError {
+	start := bytes.LastIndexByte(t.line[:pos], eol) + 1
+	end := len(t.line)
+	if n := bytes.IndexByte(t.line[start:], eol); n >= 0 {
+		end = start + n
+	}
+	// center the snippet on the error when the line is too long
+	lo, hi := start, end
+	if pos-lo > snippetSize/2 {
+		lo = pos - snippetSize/2
+	}
+	if hi-lo > snippetSize {
+		hi = lo + snippetSize
+	}
+	return &ParseError{
+		Line:   t.lineNo + bytes.Count(t.line[:start], []byte{eol}),
+		Offset: t.lineOffset + int64(pos),
+		Column: pos - start + 1,
+		Text:   string(bytes.ToValidUTF8(t.line[lo:hi], nil)),
+		Err:    err,
+	}
+}
+
+// maxEscape is the length of the longest escape sequence: \UXXXXXXXX
+const maxEscape = 10
+
+// isEscape returns true if 'p' starts with a valid escape sequence
+func isEscape(p []byte) bool {
+	if len(p) > maxEscape {
+		p = p[:maxEscape]
+	}
+	_, _, _, err := strconv.UnquoteChar(string(p), '"')
+	return err == nil
+}
+
+// appendUnquote appends the decoded content of a quoted string to 'dst', the same way strconv.Unquote does.
+//
+// It is more general than the usual definition: an invalid escape sequence
+// is not an error, the character right after '\' is kept as is.
+func appendUnquote(dst, raw []byte) []byte {
+	for len(raw) > 0 {
+		i := bytes.IndexByte(raw, '\\')
+		if i < 0 {
+			return append(dst, raw...)
+		}
+		dst, raw = append(dst, raw[:i]...), raw[i:]
+
+		n := len(raw)
+		if n > maxEscape {
+			n = maxEscape
+		}
+		seq := string(raw[:n])
+		r, multibyte, tail, err := strconv.UnquoteChar(seq, '"')
+		if err != nil {
+			// skip the '\', and keep the next char as is
+			_, size := utf8.DecodeRune(raw[1:])
+			dst, raw = append(dst, raw[1:1+size]...), raw[1+size:]
+			continue
+		}
+		if r < utf8.RuneSelf || !multibyte {
+			dst = append(dst, byte(r)) // \x and octal escapes are bytes
+		} else {
+			dst = utf8.AppendRune(dst, r)
+		}
+		raw = raw[n-len(tail):]
+	}
+	return dst
+}
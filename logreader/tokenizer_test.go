@@ -0,0 +1,100 @@
+package logreader
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleTokenizer() {
+
+	src := `at=1234578 debug path=/login user="foo@bar.com"
+	at=1234589 debug path=/login user="bar\tbar.com"
+	`
+
+	t := NewTokenizer(strings.NewReader(src))
+	for t.Scan() {
+		var pairs []string
+		for key, value, hasValue := t.Next(); key != nil; key, value, hasValue = t.Next() {
+			if hasValue {
+				pairs = append(pairs, fmt.Sprintf("%s=%q", key, value))
+			} else {
+				pairs = append(pairs, string(key))
+			}
+		}
+		fmt.Println(strings.Join(pairs, " "))
+	}
+	//Output:
+	// at="1234578" debug path="/login" user="foo@bar.com"
+	// at="1234589" debug path="/login" user="bar\tbar.com"
+}
+
+func TestTokenizerMultiline(t *testing.T) {
+
+	tok := NewTokenizer(strings.NewReader("a=\"multi\nline\" b\nc=3"))
+	var lines []string
+	for tok.Scan() {
+		var pairs []string
+		for key, value, _ := tok.Next(); key != nil; key, value, _ = tok.Next() {
+			pairs = append(pairs, fmt.Sprintf("%s:%s", key, value))
+		}
+		lines = append(lines, strings.Join(pairs, ","))
+	}
+	if got := strings.Join(lines, "|"); got != "a:multi\nline,b:|c:3" {
+		t.Errorf("invalid tokens %q", got)
+	}
+}
+
+func TestTokenizerAllocs(t *testing.T) {
+
+	line := []byte(`at=1234578 debug path=/login user="foo\tbar.com" msg="hello world" load=12ms` + "\n")
+	src := bytes.NewReader(nil)
+	tok := NewTokenizer(src)
+	allocs := testing.AllocsPerRun(100, func() {
+		src.Reset(line)
+		tok.src.Reset(src)
+		tok.eof = false
+		for tok.Scan() {
+			for key, _, _ := tok.Next(); key != nil; key, _, _ = tok.Next() {
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("tokenizing a line allocates %v times", allocs)
+	}
+}
+
+// benchSource returns a large logfmt stream
+func benchSource() []byte {
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&buf, "at=%d debug path=/login user=\"user%d@bar.com\" msg=\"hello\\tworld\" load=12ms\n", i, i)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkTokenizer(b *testing.B) {
+	src := benchSource()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		t := NewTokenizer(bytes.NewReader(src))
+		for t.Scan() {
+			for key, _, _ := t.Next(); key != nil; key, _, _ = t.Next() {
+			}
+		}
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	src := benchSource()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r := New(bytes.NewReader(src))
+		for r.HasNext() {
+			r.Next()
+		}
+	}
+}
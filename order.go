@@ -0,0 +1,79 @@
+package logfmt
+
+import "sort"
+
+// KeyOrder defines the order of the attributes in a line: it returns true if
+// key 'a' must be written before key 'b'.
+type KeyOrder func(a, b string) bool
+
+// Insertion keeps the attributes in the order they were inserted.
+//
+// It is the Logger default: Fields are written in order. A Record, being a
+// map, has no insertion order: it is written in Significance order.
+var Insertion KeyOrder
+
+// Significance order: general keys (short name) come first then specific
+// attributes (long name), keys of the same length are sorted alphabetically.
+func Significance(a, b string) bool {
+	if len(a) != len(b) { //primary key (key length) is significant
+		return len(a) < len(b) //use it
+	}
+	//default use the secondary key: lexicographic
+	return a < b
+}
+
+// Alphabetical order.
+func Alphabetical(a, b string) bool { return a < b }
+
+// Priority returns a KeyOrder that writes 'keys' first, in that order, then
+// the other keys in 'then' order (Significance if nil).
+//
+//    Priority(Alphabetical, "time", "level", "msg")
+func Priority(then KeyOrder, keys ...string) KeyOrder {
+	if then == nil {
+		then = Significance
+	}
+	rank := make(map[string]int, len(keys))
+	for i, k := range keys {
+		if _, exists := rank[k]; !exists {
+			rank[k] = i
+		}
+	}
+	return func(a, b string) bool {
+		ra, pa := rank[a]
+		rb, pb := rank[b]
+		switch {
+		case pa && pb:
+			return ra < rb
+		case pa || pb:
+			return pa
+		default:
+			return then(a, b)
+		}
+	}
+}
+
+// Ordered returns the Record's attributes as Fields, in 'order' (Significance if nil).
+func (rec Record) Ordered(order KeyOrder) Fields {
+	if order == nil {
+		order = Significance
+	}
+	f := make(Fields, 0, len(rec))
+	for k, v := range rec {
+		f = append(f, Pair{Key: k, Val: v})
+	}
+	sort.Slice(f, func(i, j int) bool { return order(f[i].Key, f[j].Key) })
+	return f
+}
+
+// Ordered returns a copy of the Fields in 'order', keeping the order of
+// equivalent keys. Insertion order (nil) returns the Fields unchanged.
+func (f Fields) Ordered(order KeyOrder) Fields {
+	if order == nil {
+		return f
+	}
+	o := make(Fields, len(f))
+	copy(o, f)
+	sort.SliceStable(o, func(i, j int) bool { return order(o[i].Key, o[j].Key) })
+	return o
+}
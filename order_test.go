@@ -0,0 +1,29 @@
+package logfmt
+
+import "fmt"
+
+func ExampleRecord_Ordered() {
+	rec := *S("msg", "started").S("time", "12:00").S("at", "info")
+	fmt.Println(rec)
+	fmt.Println(rec.Ordered(Alphabetical))
+	fmt.Println(rec.Ordered(Priority(Alphabetical, "time", "msg")))
+	//Output:
+	// at=info msg=started time=12:00
+	// at=info msg=started time=12:00
+	// time=12:00 msg=started at=info
+}
+
+func ExampleFields_Ordered() {
+	f := Fields{{"msg", nil}, {"b", nil}, {"time", nil}, {"a", nil}}
+	fmt.Println(f.Ordered(Insertion))
+	fmt.Println(f.Ordered(Priority(nil, "time")))
+	//Output:
+	// msg b time a
+	// time a b msg
+}
+
+func ExampleRecord_StringIn() {
+	rec := *S("msg", "started").S("time", "12:00").S("at", "info")
+	fmt.Println(rec.StringIn(Priority(Alphabetical, "time", "msg")))
+	//Output: time=12:00 msg=started at=info
+}
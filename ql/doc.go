@@ -10,7 +10,7 @@
 //
 // A ql statement can be evaluated on any given Record, it might return one of the following runtime type:
 //
-//    - *string: for the raw attribute value
+//    - *string: for the attribute value (unquoted)
 //    - bool: as the result of any comparison
 //    - *regexp.Regexp: for regexp Literal
 //    - int64: for numbers
@@ -244,7 +244,21 @@ func AsValue(xval interface{}, xerr error) (val *string, err error) {
 	return
 }
 
+// unquote returns the value of a quoted string literal, other values are returned as is.
+func unquote(v *string) *string {
+	if v == nil || !strings.HasPrefix(*v, `"`) {
+		return v
+	}
+	s, err := strconv.Unquote(*v)
+	if err != nil {
+		return v
+	}
+	return &s
+}
+
 // Eval the expr using a 'rec' map of 'value'
+//
+// Quoted values (like the ones produced by logfmt.Record.Q) are evaluated unquoted.
 func Eval(expr Expr, rec map[string]*string) (val interface{}, err error) {
 
 	switch x := expr.(type) {
@@ -375,7 +389,7 @@ func Eval(expr Expr, rec map[string]*string) (val interface{}, err error) {
 
 		case IDENT: // *string
 			ident := x.Value[1:]
-			val = rec[ident]
+			val = unquote(rec[ident])
 			return
 
 		case NUMBER: // int64
@@ -55,6 +55,11 @@ var (
 			`.a ~ /path\/sub.*/`,
 			`true`,
 		},
+		{
+			`user="John \"Doe\""`,
+			`.user ~ /^John "Doe"$/`,
+			`true`,
+		},
 	}
 )
 
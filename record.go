@@ -3,9 +3,17 @@ package logfmt
 import (
 	"bufio"
 	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"reflect"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 //Record contains a single logfmt line.
@@ -38,6 +46,33 @@ func K(key string) *Record { return Rec().K(key) }
 // V creates a Record,call V method, and return the Record
 func V(key string, value interface{}) *Record { return Rec().V(key, value) }
 
+// Int64 creates a Record, call Int64 method, and return the Record
+func Int64(key string, val int64) *Record { return Rec().Int64(key, val) }
+
+// Uint64 creates a Record, call Uint64 method, and return the Record
+func Uint64(key string, val uint64) *Record { return Rec().Uint64(key, val) }
+
+// Time creates a Record, call Time method, and return the Record
+func Time(key string, val time.Time) *Record { return Rec().Time(key, val) }
+
+// Duration creates a Record, call Duration method, and return the Record
+func Duration(key string, val time.Duration) *Record { return Rec().Duration(key, val) }
+
+// Err creates a Record, call Err method, and return the Record
+func Err(key string, err error) *Record { return Rec().Err(key, err) }
+
+// Hex creates a Record, call Hex method, and return the Record
+func Hex(key string, val []byte) *Record { return Rec().Hex(key, val) }
+
+// Base64 creates a Record, call Base64 method, and return the Record
+func Base64(key string, val []byte) *Record { return Rec().Base64(key, val) }
+
+// TimeFormat is the layout used to write times, see time.Time.Format.
+var TimeFormat = time.RFC3339Nano
+
+// CauseSuffix is appended to an error key, to write the root cause of the error.
+const CauseSuffix = ".cause"
+
 // set stores an attribute and return the record pointer
 func (rec *Record) set(key string, val *string) *Record { (*rec)[key] = val; return rec }
 
@@ -51,49 +86,32 @@ func (rec *Record) Q(key, val string) *Record {
 //
 // 'val' format is checked and quoted if needed.
 func (rec *Record) S(key, val string) *Record {
-
-	buf := bytes.NewBuffer([]byte(val))
-	r, _, _ := buf.ReadRune()
-
 	// either 'val' is correct:
-	//      - it's a string literal (it starts with a ")
-	//      - it's an identifier (no spaces)
+	//      - it's an identifier (not empty, no spaces, no '"' nor '=')
+	//      - it's a string literal (it starts with a "), it is used as is, but in the Q canonical form
 	// OR: 'val' is not correct and it need to be escaped
-	eof := '\x00'
-	if r == '"' { //it should  be a string
-		for r, _, _ = buf.ReadRune(); r != eof && r != '"'; r, _, _ = buf.ReadRune() {
-			if r == '\\' {
-				r, _, _ = buf.ReadRune() // always read the next after \ whatever it is
-				if !(r == 'a' || r == 'b' || r == 'f' || r == 'n' || r == 'r' || r == 't' || r == 'v') {
-					// not a valid escape char
-					return Q(key, val)
-				}
-			}
-		}
-		//end of the string or the src? that is the question, I MUST match the end of string, and then the end of file
-		if r == eof {
-			// this is an error //Escape the code
-			return Q(key, val)
-		} // r must be " this is the only possible other outcome, but now it must be the latest value
-		r, _, _ = buf.ReadRune()
-		if r != eof { // oups, there is extra stuff after the end '"'
-			return Q(key, val)
-		}
-		//valid string, no escape needed
-
-	} else { // this is the other possible case: 'val' has to be a a valid identifier
-		//read until it finds the eof or ' '
-		for r, _, _ = buf.ReadRune(); r != eof && r > ' '; r, _, _ = buf.ReadRune() {
+	if isIdentifier(val) {
+		return rec.set(key, &val)
+	}
+	if strings.HasPrefix(val, `"`) {
+		if str, err := strconv.Unquote(val); err == nil {
+			return rec.Q(key, str)
 		}
+	}
+	return rec.Q(key, val)
+}
 
-		// now the only valid outcome is to end on eof, because space is not allowed
-		if r != eof {
-			return Q(key, val)
+// isIdentifier returns true if 'val' can be written unquoted, and read back as is.
+func isIdentifier(val string) bool {
+	if val == "" {
+		return false // 'key=' is ambiguous
+	}
+	for _, r := range val {
+		if r <= ' ' || r == '"' || r == '=' || r == utf8.RuneError {
+			return false
 		}
-		// valid identifier no escape needed
 	}
-	// default situation, no escape needed,all other cases have been taken into account
-	return rec.set(key, &val)
+	return true
 }
 
 // D insert an integer attribute `key=12`
@@ -117,43 +135,194 @@ func (rec *Record) G(key string, val float64) *Record {
 // K insert a key only attribute `debug verbose`
 func (rec *Record) K(key string) *Record { (*rec)[key] = nil; return rec }
 
+// Marshaler is implemented by types that control how they are logged by V.
+//
+// MarshalLogfmt inserts the value into 'rec', under 'key', or under several
+// sub-keys like `key.x=1 key.y=2`.
+type Marshaler interface {
+	MarshalLogfmt(key string, rec *Record)
+}
+
 // V insert value using fmt.Printf verb "%v".
 //
-// The result is quoted if necessary
+// Unless 'value' is a Marshaler, an encoding.TextMarshaler, a time.Time, a
+// time.Duration, or a fmt.Stringer (used by "%v"). The result is quoted if
+// necessary. A nil pointer is written `key=<nil>`, its methods are not called.
 func (rec *Record) V(key string, value interface{}) *Record {
+	switch v := value.(type) {
+	case Marshaler:
+		if isNil(v) {
+			return rec.S(key, "<nil>")
+		}
+		v.MarshalLogfmt(key, rec)
+		return rec
+	case time.Time:
+		return rec.Time(key, v)
+	case time.Duration:
+		return rec.Duration(key, v)
+	case encoding.TextMarshaler:
+		if !isNil(v) {
+			if text, err := v.MarshalText(); err == nil {
+				return rec.S(key, string(text))
+			}
+		}
+	}
 	//we use S to protect the value
 	return rec.S(key, fmt.Sprint(value))
 }
 
-// String format the current record as a string
-func (rec Record) String() string {
+// isNil returns true if 'v' is a nil pointer, its methods might panic
+func isNil(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// Int64 insert a 64-bit integer attribute `key=12`
+func (rec *Record) Int64(key string, val int64) *Record {
+	x := strconv.FormatInt(val, 10)
+	return rec.set(key, &x)
+}
+
+// Uint64 insert an unsigned 64-bit integer attribute `key=12`
+func (rec *Record) Uint64(key string, val uint64) *Record {
+	x := strconv.FormatUint(val, 10)
+	return rec.set(key, &x)
+}
+
+// Time insert a time attribute `key=2006-01-02T15:04:05.999999999Z07:00`, in TimeFormat.
+func (rec *Record) Time(key string, val time.Time) *Record {
+	return rec.S(key, val.Format(TimeFormat))
+}
+
+// Duration insert a duration attribute `key=1m30s`
+func (rec *Record) Duration(key string, val time.Duration) *Record {
+	x := val.String()
+	return rec.set(key, &x)
+}
+
+// Err insert an error attribute `key="open x: permission denied"`.
+//
+// If 'err' wraps other errors, the root cause is also inserted in the key
+// followed by CauseSuffix: `key.cause="permission denied"`. A nil error is
+// not inserted.
+func (rec *Record) Err(key string, err error) *Record {
+	if err == nil {
+		return rec
+	}
+	rec.Q(key, err.Error())
+	if cause := rootCause(err); cause != err {
+		rec.Q(key+CauseSuffix, cause.Error())
+	}
+	return rec
+}
+
+// rootCause returns the innermost error wrapped by 'err', the first one if
+// several errors are wrapped.
+func rootCause(err error) error {
+	for {
+		var next error
+		switch x := err.(type) {
+		case interface{ Unwrap() error }:
+			next = x.Unwrap()
+		case interface{ Unwrap() []error }:
+			if errs := x.Unwrap(); len(errs) > 0 {
+				next = errs[0]
+			}
+		}
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+// Hex insert a []byte attribute, in hexadecimal `key=cafe`
+func (rec *Record) Hex(key string, val []byte) *Record {
+	return rec.S(key, hex.EncodeToString(val))
+}
+
+// Base64 insert a []byte attribute, in standard base64 encoding `key="yv4="`
+func (rec *Record) Base64(key string, val []byte) *Record {
+	return rec.S(key, base64.StdEncoding.EncodeToString(val))
+}
+
+// String format the current record as a string, in Significance order, see StringIn
+func (rec Record) String() string { return rec.StringIn(nil) }
+
+// StringIn format the current record as a string, in 'order' (Significance if nil).
+//
+// It is the Logger.SetOrder policy for a single record:
+//
+//    rec.StringIn(logfmt.Priority(logfmt.Alphabetical, "time", "msg"))
+func (rec Record) StringIn(order KeyOrder) string {
 
-	// I can't reuse the fastLog, because this one need to be threadsafe
 	var buffer bytes.Buffer
 	buf := bufio.NewWriter(&buffer)
 
-	L := len(rec)
-	keyvals = &fastKeySorter{
-		keys: make([]string, 0, L),
-		vals: make([]*string, 0, L),
-	}
-	logTo(buf, rec, keyvals)
+	sorter := getSorter()
+	sorter.less = order
+	logTo(buf, rec, sorter)
+	putSorter(sorter)
 	buf.Flush()
 	return buffer.String()
 }
 
+// writer is what lines are formatted into: a *bufio.Writer or a *bytes.Buffer
+type writer interface {
+	WriteString(s string) (int, error)
+	WriteRune(r rune) (int, error)
+}
+
 //logTo log a record into the buf, using the fastKeySorter struct to fill in
 // the fastKeySorter can be reused (for speeding up the process)
-func logTo(buf *bufio.Writer, rec Record, sorter *fastKeySorter) {
-	L := len(rec)
+func logTo(buf writer, rec Record, sorter *fastKeySorter) {
 	for k, v := range rec {
-		keyvals.keys = append(keyvals.keys, k)
-		keyvals.vals = append(keyvals.vals, v)
+		sorter.add(k, v)
+	}
+	sort.Sort(sorter)
+	sorter.writeTo(buf)
+}
+
+type fastKeySorter struct {
+	keys []string
+	vals []*string
+	less KeyOrder // Significance if nil
+}
+
+// a pool of sorters, they are reused to speed up the process, safely
+var sorters = sync.Pool{
+	New: func() interface{} {
+		return &fastKeySorter{
+			keys: make([]string, 0, 16),
+			vals: make([]*string, 0, 16),
+		}
+	},
+}
+
+// getSorter returns an empty sorter from the pool
+func getSorter() *fastKeySorter { return sorters.Get().(*fastKeySorter) }
+
+// putSorter returns the sorter to the pool
+func putSorter(s *fastKeySorter) {
+	for i := range s.vals {
+		s.keys[i], s.vals[i] = "", nil // do not retain the records
 	}
-	sort.Sort(keyvals)
+	s.keys = s.keys[0:0]
+	s.vals = s.vals[0:0]
+	s.less = nil
+	sorters.Put(s)
+}
 
-	for i := 0; i < L; i++ {
-		key, val := keyvals.keys[i], keyvals.vals[i]
+// add an attribute to the sorter
+func (s *fastKeySorter) add(key string, val *string) {
+	s.keys = append(s.keys, key)
+	s.vals = append(s.vals, val)
+}
+
+// writeTo writes the attributes, in the sorter order
+func (s *fastKeySorter) writeTo(buf writer) {
+	for i, key := range s.keys {
+		val := s.vals[i]
 
 		if i > 0 {
 			buf.WriteRune(' ')
@@ -166,21 +335,16 @@ func logTo(buf *bufio.Writer, rec Record, sorter *fastKeySorter) {
 	}
 }
 
-type fastKeySorter struct {
-	keys []string
-	vals []*string
-}
-
 func (s *fastKeySorter) Len() int { return len(s.keys) }
 func (s *fastKeySorter) Swap(i, j int) {
 	s.keys[i], s.keys[j], s.vals[i], s.vals[j] = s.keys[j], s.keys[i], s.vals[j], s.vals[i]
 }
-func (s *fastKeySorter) Less(i, j int) bool {
-	ki, kj := s.keys[i], s.keys[j]
-	lki, lkj := len(ki), len(kj)
-	if lki != lkj { //primary key (key length) is significant
-		return lki < lkj //use it
+func (s *fastKeySorter) Less(i, j int) bool { return s.before(s.keys[i], s.keys[j]) }
+
+// before compares two keys in the sorter order
+func (s *fastKeySorter) before(a, b string) bool {
+	if s.less == nil {
+		return Significance(a, b)
 	}
-	//default use the secondary key: lexicographic
-	return ki < kj
+	return s.less(a, b)
 }
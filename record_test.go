@@ -1,6 +1,7 @@
 package logfmt
 
 import (
+	"fmt"
 	"time"
 
 	"os"
@@ -69,3 +70,56 @@ func ExampleRec() {
 	r.D("load", 125)
 	//Output: load=125 debug
 }
+
+func ExampleTime() {
+	Default = New(os.Stdout)
+	start := time.Date(2024, 3, 1, 12, 30, 0, 500000000, time.UTC)
+	Time("start", start).Duration("elapsed", 1500*time.Millisecond).Log()
+	//Output: start=2024-03-01T12:30:00.5Z elapsed=1.5s
+}
+
+func ExampleErr() {
+	Default = New(os.Stdout)
+	err := fmt.Errorf("loading config: %w", &os.PathError{Op: "open", Path: "app.conf", Err: os.ErrPermission})
+	Err("error", err).Log()
+	//Output: error="loading config: open app.conf: permission denied" error.cause="permission denied"
+}
+
+func ExampleInt64() {
+	Default = New(os.Stdout)
+	Int64("offset", -1<<40).Uint64("size", 1<<63).Log()
+	//Output: size=9223372036854775808 offset=-1099511627776
+}
+
+func ExampleHex() {
+	Default = New(os.Stdout)
+	Hex("id", []byte{0xca, 0xfe}).Base64("sig", []byte{0xca, 0xfe}).Log()
+	//Output: id=cafe sig="yv4="
+}
+
+// point is logged as two sub-keys
+type point struct{ X, Y int }
+
+func (p point) MarshalLogfmt(key string, rec *Record) { rec.D(key+".x", p.X).D(key+".y", p.Y) }
+
+// color is logged by name
+type color int
+
+func (c color) MarshalText() ([]byte, error) { return []byte([]string{"red", "green"}[c]), nil }
+
+// celsius has a String method
+type celsius float64
+
+func (c celsius) String() string { return fmt.Sprintf("%.1f°C", float64(c)) }
+
+func ExampleMarshaler() {
+	Default = New(os.Stdout)
+	V("at", point{3, 4}).V("color", color(1)).V("temp", celsius(21.5)).Log()
+	//Output: at.x=3 at.y=4 temp=21.5°C color=green
+}
+
+func ExampleRecord_V_nil() {
+	Default = New(os.Stdout)
+	V("p", (*point)(nil)).V("c", (*color)(nil)).Log() // their methods are not called
+	//Output: c=<nil> p=<nil>
+}
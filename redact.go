@@ -0,0 +1,168 @@
+package logfmt
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Common patterns of sensitive values, for Redactor.ValuePatterns.
+//
+// Numbers matching CardNumberPattern are only redacted if they pass the Luhn
+// checksum of card numbers: timestamps and ids are mostly kept.
+var (
+	BearerTokenPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	CardNumberPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// DefaultMask replaces redacted values, unless Redactor.Mask is set.
+const DefaultMask = "***"
+
+// Redactor masks sensitive values before they are written.
+//
+// The whole value of sensitive keys is masked, and so are the parts of any
+// value matching a pattern:
+//
+//    logger.SetRedactor(&Redactor{
+//        Keys:          []string{"password", "token"},
+//        KeyPatterns:   []*regexp.Regexp{regexp.MustCompile(`(?i)secret`)},
+//        ValuePatterns: []*regexp.Regexp{BearerTokenPattern, CardNumberPattern},
+//    })
+//
+// Hashes let you correlate records with the same sensitive value, like the
+// same user id, without writing the value: equal values have the same hash.
+// They are HMAC-SHA256 keyed by Key, so they cannot be reversed, even for
+// guessable values like passwords, without the Key. Records hashed with
+// different keys, like by two processes without Key, cannot be correlated.
+//
+// A Redactor must not be changed once in use.
+type Redactor struct {
+	Keys          []string         // sensitive keys, also matching the last part of dotted keys: 'password' matches 'user.password'
+	KeyPatterns   []*regexp.Regexp // sensitive keys patterns
+	ValuePatterns []*regexp.Regexp // sensitive values patterns
+	Mask          string           // DefaultMask if empty
+	Hash          bool             // replace sensitive values by their keyed hash instead, see below
+	Key           []byte           // the key of the hashes, random for each process if nil
+}
+
+// processKey is the key of the hashes of Redactors without Key
+var processKey = sync.OnceValue(func() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+})
+
+// sensitive returns true if the values of 'key' are sensitive
+func (r *Redactor) sensitive(key string) bool {
+	for _, k := range r.Keys {
+		if key == k || strings.HasSuffix(key, "."+k) {
+			return true
+		}
+	}
+	for _, p := range r.KeyPatterns {
+		if p.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// replace a sensitive value by the mask or its hash
+func (r *Redactor) replace(s string) string {
+	if r.Hash {
+		key := r.Key
+		if key == nil {
+			key = processKey()
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+	}
+	if r.Mask == "" {
+		return DefaultMask
+	}
+	return r.Mask
+}
+
+// Value returns the redacted value of the attribute 'key', 'val' itself if
+// it is not sensitive. A nil Redactor redacts nothing.
+func (r *Redactor) Value(key string, val *string) *string {
+	if r == nil || val == nil {
+		return val
+	}
+	if r.sensitive(key) {
+		return literal(r.replace(unquote(*val)))
+	}
+	if len(r.ValuePatterns) == 0 {
+		return val
+	}
+	s := unquote(*val)
+	red := s
+	for _, p := range r.ValuePatterns {
+		replace := r.replace
+		if p == CardNumberPattern {
+			replace = func(m string) string {
+				if !luhn(m) {
+					return m
+				}
+				return r.replace(m)
+			}
+		}
+		red = p.ReplaceAllStringFunc(red, replace)
+	}
+	if red == s {
+		return val
+	}
+	return literal(red)
+}
+
+// luhn returns true if the digits of 's' pass the Luhn checksum, other chars are ignored
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Record returns a redacted copy of 'rec'.
+func (r *Redactor) Record(rec Record) Record {
+	c := make(Record, len(rec))
+	for k, v := range rec {
+		c[k] = r.Value(k, v)
+	}
+	return c
+}
+
+// Fields returns a redacted copy of 'f'.
+func (r *Redactor) Fields(f Fields) Fields {
+	c := make(Fields, len(f))
+	for i, p := range f {
+		c[i] = Pair{Key: p.Key, Val: r.Value(p.Key, p.Val)}
+	}
+	return c
+}
+
+// literal returns the value of 's', quoted if needed, the way Record.S does
+func literal(s string) *string {
+	if !isIdentifier(s) {
+		s = strconv.Quote(s)
+	}
+	return &s
+}
@@ -0,0 +1,69 @@
+package logfmt
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func ExampleRedactor() {
+	logger := New(os.Stdout)
+	logger.SetRedactor(&Redactor{
+		Keys:          []string{"password"},
+		KeyPatterns:   []*regexp.Regexp{regexp.MustCompile(`(?i)token`)},
+		ValuePatterns: []*regexp.Regexp{BearerTokenPattern, CardNumberPattern},
+	})
+	logger.Log(*S("user", "bob").S("password", "hunter2").S("apiToken", "abc"))
+	logger.Log(*Q("auth", "Bearer eyJhbGciOi.xyz").Q("note", "card 4111 1111 1111 1111 declined"))
+
+	//Output:
+	// user=bob apiToken=*** password=***
+	// auth=*** note="card *** declined"
+}
+
+func TestRedactor(t *testing.T) {
+	r := &Redactor{Keys: []string{"password"}, Hash: true}
+	rec := *S("password", "hunter2").S("user.password", "hunter2").S("user", "bob").K("password2")
+
+	red := r.Record(rec)
+	a, b := red["password"], red["user.password"]
+	if a == nil || *a == "hunter2" || *a != *b {
+		t.Errorf("sensitive values must be replaced by the same hash: %v", red)
+	}
+	if red["user"] != rec["user"] || !red.IsFlag("password2") {
+		t.Errorf("other attributes must be unchanged: %v", red)
+	}
+	if v, _ := rec.Str("password"); v != "hunter2" {
+		t.Errorf("the record must be unchanged: %v", rec)
+	}
+
+	k1 := (&Redactor{Keys: []string{"password"}, Hash: true, Key: []byte("k1")}).Record(rec)
+	k2 := (&Redactor{Keys: []string{"password"}, Hash: true, Key: []byte("k2")}).Record(rec)
+	if !strings.HasPrefix(*k1["password"], "hmac:") || *k1["password"] == *k2["password"] {
+		t.Errorf("hashes must be keyed: %v %v", k1, k2)
+	}
+
+	numbers := *S("ts", "1709294400123456789").S("id", "1234567890123456789").Q("card", "4111-1111-1111-1111")
+	red = (&Redactor{ValuePatterns: []*regexp.Regexp{CardNumberPattern}}).Record(numbers)
+	if got := red.String(); got != "id=1234567890123456789 ts=1709294400123456789 card=***" {
+		t.Errorf("only card numbers must be masked: %q", got)
+	}
+
+	var nilRedactor *Redactor
+	if v := nilRedactor.Value("password", rec["password"]); v != rec["password"] {
+		t.Errorf("a nil Redactor must redact nothing")
+	}
+	f := Fields{{Key: "password", Val: rec["password"]}}
+	if got := (&Redactor{Keys: []string{"password"}, Mask: "[hidden]"}).Fields(f).String(); got != "password=[hidden]" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func ExampleLogger_SetRedactor() {
+	logger := New(os.Stdout)
+	child := logger.With(*S("token", "abc"))
+	logger.SetRedactor(&Redactor{Keys: []string{"token"}})
+	child.Log(*S("msg", "hello"))
+	//Output: msg=hello token=***
+}
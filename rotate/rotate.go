@@ -0,0 +1,293 @@
+// Package rotate provides a rotating file writer, for logfmt Loggers.
+//
+//    f := &rotate.File{Path: "app.log", MaxSize: 10 << 20, MaxFiles: 5, Compress: true}
+//    logger := logfmt.New(f)
+//    defer logger.Close() // closes the file too
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeFormat is the layout of the time a file is rotated, inserted in its name
+const timeFormat = "2006-01-02T15-04-05.000"
+
+// File is an io.WriteCloser writing to the file Path, that is rotated when it
+// gets too big, or too old.
+//
+// Rotated files are renamed with their rotation time, before the extension:
+// 'app.log' becomes 'app-2024-03-01T12-00-00.000.log', and then compressed
+// into 'app-2024-03-01T12-00-00.000.log.gz' if Compress is set.
+//
+// Files are rotated between two lines only: a line is never split between two
+// files, even if it is written by several calls to Write.
+//
+// It is safe for concurrent use. Its fields must be set before the first Write.
+type File struct {
+	Path     string        // the path of the current file
+	MaxSize  int64         // rotate before the file exceeds MaxSize bytes, no limit if zero
+	Interval time.Duration // rotate at each multiple of Interval (24h rotates at midnight UTC), never if zero
+	MaxFiles int           // the maximum number of rotated files to keep, no limit if zero
+	MaxAge   time.Duration // the maximum age of rotated files to keep, no limit if zero
+	Compress bool          // gzip the rotated files
+	Now      func() time.Time
+
+	lock     sync.Mutex // guards the fields below
+	file     *os.File
+	size     int64
+	deadline time.Time // the next rotation time, if Interval is set
+	midLine  bool      // true if the last Write has not ended a line
+
+	mill sync.Mutex     // to compress and remove rotated files one at a time
+	wg   sync.WaitGroup // the pending compressions
+}
+
+// now returns the time of the File clock
+func (f *File) now() time.Time {
+	if f.Now == nil {
+		return time.Now()
+	}
+	return f.Now()
+}
+
+// Write writes 'p' to the current file, rotating it first if needed.
+func (f *File) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if !f.midLine && f.size > 0 && f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	} else if f.size == 0 && f.Interval > 0 {
+		// nothing to rotate yet, the interval starts with the first line
+		if now := f.now(); !now.Before(f.deadline) {
+			f.deadline = now.Truncate(f.Interval).Add(f.Interval)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if n > 0 {
+		f.midLine = p[n-1] != '\n'
+	}
+	return n, err
+}
+
+// due returns true if the current file must be rotated before writing 'n' bytes
+func (f *File) due(n int64) bool {
+	if f.MaxSize > 0 && f.size+n > f.MaxSize {
+		return true
+	}
+	return f.Interval > 0 && !f.now().Before(f.deadline)
+}
+
+// open the current file, appending to it if it exists
+func (f *File) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size, f.midLine = file, info.Size(), false
+	if f.Interval > 0 {
+		f.deadline = f.now().Truncate(f.Interval).Add(f.Interval)
+	}
+	return nil
+}
+
+// Rotate closes the current file, renames it, and opens a new one.
+func (f *File) Rotate() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	return f.rotate()
+}
+
+// rotate the current file, the lock must be held
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	now := f.now()
+	rotated := f.rotatedName(now.UTC())
+	if err := os.Rename(f.Path, rotated); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.mill.Lock()
+		defer f.mill.Unlock()
+		if f.Compress {
+			compress(rotated) // on failure the file is kept uncompressed
+		}
+		f.prune(now)
+	}()
+	return nil
+}
+
+// rotatedName returns an unused name for the file rotated at 't'
+func (f *File) rotatedName(t time.Time) string {
+	ext := filepath.Ext(f.Path)
+	prefix := strings.TrimSuffix(f.Path, ext) + "-" + t.Format(timeFormat)
+	name := prefix + ext
+	for i := 1; exists(name) || exists(name+".gz"); i++ {
+		name = prefix + "-" + strconv.Itoa(i) + ext
+	}
+	return name
+}
+
+// exists returns true if the file 'name' exists
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// compress 'name' into 'name.gz', and removes it
+func compress(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+	return os.Remove(name)
+}
+
+// Rotated returns the rotated files, from the oldest to the newest.
+func (f *File) Rotated() ([]string, error) {
+	ext := filepath.Ext(f.Path)
+	base := filepath.Base(strings.TrimSuffix(f.Path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.Path))
+	if err != nil {
+		return nil, err
+	}
+	var rotated []string
+	order := make(map[string]rotation)
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || !(strings.HasSuffix(name, ext) || strings.HasSuffix(name, ext+".gz")) {
+			continue
+		}
+		if r, ok := parseRotation(name[len(base):]); ok {
+			path := filepath.Join(filepath.Dir(f.Path), name)
+			rotated = append(rotated, path)
+			order[path] = r
+		}
+	}
+	sort.Slice(rotated, func(i, j int) bool {
+		a, b := order[rotated[i]], order[rotated[j]]
+		if !a.t.Equal(b.t) {
+			return a.t.Before(b.t)
+		}
+		return a.n < b.n
+	})
+	return rotated, nil
+}
+
+// rotation is the rotation time of a file, and its collision suffix, 0 if none
+type rotation struct {
+	t time.Time
+	n int
+}
+
+// parseRotation parses the rotation time, and the collision suffix, at the beginning of 's'
+func parseRotation(s string) (rotation, bool) {
+	t, ok := rotationTime(s)
+	if !ok {
+		return rotation{}, false
+	}
+	r := rotation{t: t}
+	if rest := s[len(timeFormat):]; strings.HasPrefix(rest, "-") {
+		digits := rest[1:]
+		if i := strings.IndexFunc(digits, func(c rune) bool { return c < '0' || c > '9' }); i >= 0 {
+			digits = digits[:i]
+		}
+		r.n, _ = strconv.Atoi(digits)
+	}
+	return r, true
+}
+
+// rotationTime parses the rotation time at the beginning of 's'
+func rotationTime(s string) (time.Time, bool) {
+	if len(s) < len(timeFormat) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(timeFormat, s[:len(timeFormat)])
+	return t, err == nil
+}
+
+// prune removes the rotated files beyond MaxFiles, or older than MaxAge at 'now'
+func (f *File) prune(now time.Time) {
+	if f.MaxFiles <= 0 && f.MaxAge <= 0 {
+		return
+	}
+	rotated, err := f.Rotated()
+	if err != nil {
+		return
+	}
+	ext := filepath.Ext(f.Path)
+	base := filepath.Base(strings.TrimSuffix(f.Path, ext)) + "-"
+	for i, name := range rotated {
+		t, _ := rotationTime(filepath.Base(name)[len(base):])
+		if f.MaxFiles > 0 && len(rotated)-i > f.MaxFiles || f.MaxAge > 0 && now.Sub(t) > f.MaxAge {
+			os.Remove(name)
+		}
+	}
+}
+
+// Close closes the current file, and waits for the rotated files to be compressed.
+func (f *File) Close() error {
+	f.lock.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.lock.Unlock()
+	f.wg.Wait()
+	return err
+}
@@ -0,0 +1,132 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etnz/logfmt"
+)
+
+// clock is a fake clock, for deterministic tests
+type clock struct{ t time.Time }
+
+func (c *clock) Now() time.Time { return c.t }
+
+func read(t *testing.T, name string) string {
+	t.Helper()
+	r, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var src io.Reader = r
+	if strings.HasSuffix(name, ".gz") {
+		if src, err = gzip.NewReader(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := io.ReadAll(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSize(t *testing.T) {
+	c := &clock{time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	f := &File{Path: filepath.Join(t.TempDir(), "app.log"), MaxSize: 10, MaxFiles: 2, Compress: true, Now: c.Now}
+	logger := logfmt.New(f)
+	for i := 0; i < 4; i++ {
+		logger.Log(*logfmt.D("line", i)) // 7 bytes each: a file per line
+		c.t = c.t.Add(time.Second)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := f.Rotated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("got %v want 2 files", rotated)
+	}
+	if !strings.HasSuffix(rotated[0], "app-2024-03-01T12-00-02.000.log.gz") {
+		t.Errorf("unexpected name %q", rotated[0])
+	}
+	for i, name := range append(rotated, f.Path) {
+		if got, want := read(t, name), "line="+string(rune('1'+i))+"\n"; got != want {
+			t.Errorf("%s: got %q want %q", name, got, want)
+		}
+	}
+}
+
+func TestInterval(t *testing.T) {
+	c := &clock{time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC)}
+	f := &File{Path: filepath.Join(t.TempDir(), "app.log"), Interval: 24 * time.Hour, MaxAge: 48 * time.Hour, Now: c.Now}
+	for _, s := range []string{"a\n", "b", "\n", "c\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		c.t = c.t.Add(30 * time.Second) // midnight is reached in the middle of line 'b'
+	}
+	c.t = c.t.Add(72 * time.Hour)
+	f.Write([]byte("d\n")) // the first rotated file is too old
+	f.Close()
+
+	rotated, _ := f.Rotated()
+	if len(rotated) != 1 || read(t, rotated[0]) != "c\n" || read(t, f.Path) != "d\n" {
+		t.Errorf("unexpected rotation %v: %q", rotated, read(t, f.Path))
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	// a line written in several pieces is never split between two files
+	f := &File{Path: filepath.Join(t.TempDir(), "app.log"), MaxSize: 4}
+	for _, s := range []string{"ab\n", "cd", "ef", "\n"} {
+		f.Write([]byte(s))
+	}
+	f.Close()
+	rotated, _ := f.Rotated()
+	if len(rotated) != 1 || read(t, rotated[0]) != "ab\n" || read(t, f.Path) != "cdef\n" {
+		t.Errorf("unexpected rotation %v: %q", rotated, read(t, f.Path))
+	}
+}
+
+func TestIntervalEmptyFile(t *testing.T) {
+	c := &clock{time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC)}
+	f := &File{Path: filepath.Join(t.TempDir(), "app.log"), Interval: 24 * time.Hour, Now: c.Now}
+	f.Write(nil) // the file is opened empty before midnight
+	c.t = c.t.Add(31 * time.Minute)
+	f.Write([]byte("a\n"))
+	c.t = c.t.Add(10 * time.Minute)
+	f.Write([]byte("b\n")) // in the same interval as 'a'
+	f.Close()
+
+	rotated, _ := f.Rotated()
+	if len(rotated) != 0 || read(t, f.Path) != "a\nb\n" {
+		t.Errorf("unexpected rotation %v: %q", rotated, read(t, f.Path))
+	}
+}
+
+func TestNameCollision(t *testing.T) {
+	c := &clock{time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	f := &File{Path: filepath.Join(t.TempDir(), "app.log"), MaxFiles: 1, Now: c.Now}
+	for _, s := range []string{"a\n", "b\n"} {
+		f.Write([]byte(s))
+		if err := f.Rotate(); err != nil { // at the same time
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	rotated, _ := f.Rotated()
+	if len(rotated) != 1 || !strings.HasSuffix(rotated[0], "app-2024-03-01T12-00-00.000-1.log") || read(t, rotated[0]) != "b\n" {
+		t.Errorf("the newest file must be kept: %v", rotated)
+	}
+}
@@ -0,0 +1,288 @@
+package logfmt
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SuppressedKey is the key of the summary records, reporting the number of
+// records suppressed by a Sampler, like:
+//
+//    msg="db slow" level=warn suppressed=120
+const SuppressedKey = "suppressed"
+
+// Sampler decides which records a Logger writes.
+//
+// Sample returns true if 'rec' must be written, and the summary records due,
+// that are written first, at warn level. Summaries returns the pending summary
+// records, it is called on Close.
+//
+// Samplers are shared by several goroutines.
+type Sampler interface {
+	Sample(rec Record) (keep bool, summaries []Record)
+	Summaries() []Record
+}
+
+// samplerBox holds a Sampler in an atomic.Value, that needs a single concrete type
+type samplerBox struct{ s Sampler }
+
+// SetSampler sets the Sampler of the records to write, nil to write them all.
+//
+// It is shared with the child Loggers, it is safe to call it at any time.
+func (l *Logger) SetSampler(s Sampler) { l.sampler.Store(samplerBox{s}) }
+
+// Sampler returns the Sampler of the records to write, nil if there is none.
+func (l *Logger) Sampler() Sampler {
+	box, _ := l.sampler.Load().(samplerBox)
+	return box.s
+}
+
+// sample returns true if 'rec' must be written, it writes the summaries due
+func (l *Logger) sample(rec Record) bool {
+	s := l.Sampler()
+	if s == nil {
+		return true
+	}
+	// pass on a copy: 'rec' would escape to the heap, even without Sampler
+	c := make(Record, len(rec))
+	for k, v := range rec {
+		c[k] = v
+	}
+	keep, summaries := s.Sample(c)
+	for _, sum := range summaries {
+		l.emit(LevelWarn.value(), sum)
+	}
+	return keep
+}
+
+// Sampling is a Sampler that writes the First records of each signature, then
+// every Every-th one, and suppresses the others. Counters are reset every
+// Period, and a summary record is written for each signature with suppressed
+// records.
+//
+// The signature of a record is the values of its Keys, or the whole record if
+// there are no Keys. Summary records have the signature attributes.
+//
+//    logger.SetSampler(&Sampling{First: 10, Every: 100, Keys: []string{"msg"}})
+type Sampling struct {
+	First, Every int
+	Keys         []string
+	Period       time.Duration    // one second if zero
+	Now          func() time.Time // the clock, time.Now if nil
+
+	lock       sync.Mutex
+	counts     map[string]int
+	suppressed suppressed
+}
+
+// Sample implements Sampler.
+func (s *Sampling) Sample(rec Record) (keep bool, summaries []Record) {
+	sig := signature(rec, s.Keys)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.suppressed.due(s.Period, now(s.Now)) {
+		summaries = s.suppressed.summaries()
+		s.counts = nil
+	}
+	if s.counts == nil {
+		s.counts = make(map[string]int)
+	}
+	s.counts[sig]++
+	n := s.counts[sig]
+	if n <= s.First || s.Every > 0 && (n-s.First)%s.Every == 0 {
+		return true, summaries
+	}
+	s.suppressed.add(sig, rec, s.Keys)
+	return false, summaries
+}
+
+// Summaries implements Sampler.
+func (s *Sampling) Summaries() []Record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.suppressed.summaries()
+}
+
+// RateLimit is a Sampler that limits the rate of records sharing the same Key
+// value, using a token bucket: up to Burst records at once, then Rate records
+// per second. A summary record is written every Period for each Key value
+// with suppressed records.
+//
+// Records without Key share the same bucket, so do all records if Key is empty.
+//
+//    logger.SetSampler(&RateLimit{Rate: 10, Burst: 100, Key: "msg", Period: time.Minute})
+type RateLimit struct {
+	Rate   float64
+	Burst  int
+	Key    string
+	Period time.Duration    // one second if zero
+	Now    func() time.Time // the clock, time.Now if nil
+
+	lock       sync.Mutex
+	buckets    map[string]*bucket
+	suppressed suppressed
+}
+
+// bucket of tokens, one token per record
+type bucket struct {
+	tokens float64
+	last   time.Time // the last refill
+}
+
+// Sample implements Sampler.
+func (r *RateLimit) Sample(rec Record) (keep bool, summaries []Record) {
+	var keys []string
+	if r.Key != "" {
+		keys = []string{r.Key}
+	}
+	sig := signature(rec, keys)
+	t := now(r.Now)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.suppressed.due(r.Period, t) {
+		summaries = r.suppressed.summaries()
+		r.refill(t)
+		for k, b := range r.buckets {
+			if b.tokens >= float64(r.Burst) {
+				delete(r.buckets, k) // same as a new one
+			}
+		}
+	}
+	if r.buckets == nil {
+		r.buckets = make(map[string]*bucket)
+	}
+	b := r.buckets[sig]
+	if b == nil {
+		b = &bucket{tokens: float64(r.Burst), last: t}
+		r.buckets[sig] = b
+	}
+	r.refillBucket(b, t)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, summaries
+	}
+	r.suppressed.add(sig, rec, keys)
+	return false, summaries
+}
+
+// refill all the buckets
+func (r *RateLimit) refill(t time.Time) {
+	for _, b := range r.buckets {
+		r.refillBucket(b, t)
+	}
+}
+
+// refillBucket adds the tokens earned since the last refill, up to Burst
+func (r *RateLimit) refillBucket(b *bucket, t time.Time) {
+	if elapsed := t.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * r.Rate
+		b.last = t
+	}
+	if b.tokens > float64(r.Burst) {
+		b.tokens = float64(r.Burst)
+	}
+}
+
+// Summaries implements Sampler.
+func (r *RateLimit) Summaries() []Record {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.suppressed.summaries()
+}
+
+// now returns the time of 'clock', time.Now if nil
+func now(clock func() time.Time) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock()
+}
+
+// signature of a record: the values of 'keys', or the whole record
+func signature(rec Record, keys []string) string {
+	if len(keys) == 0 {
+		return rec.String()
+	}
+	var sig strings.Builder
+	for _, k := range keys {
+		if v, exists := rec[k]; exists {
+			sig.WriteString(k)
+			if v != nil {
+				sig.WriteByte('=')
+				sig.WriteString(*v)
+			}
+		}
+		sig.WriteByte(0)
+	}
+	return sig.String()
+}
+
+// suppressed counts the suppressed records per signature, between two summaries
+type suppressed struct {
+	start  time.Time // the start of the current period
+	counts map[string]*suppression
+}
+
+// suppression counts the suppressed records of a signature
+type suppression struct {
+	rec Record // the signature attributes
+	n   int
+}
+
+// due returns true if the period started before 't' is over, and starts a new one
+func (s *suppressed) due(period time.Duration, t time.Time) bool {
+	if period <= 0 {
+		period = time.Second
+	}
+	if s.start.IsZero() {
+		s.start = t
+	}
+	if t.Sub(s.start) < period {
+		return false
+	}
+	s.start = t
+	return true
+}
+
+// add a suppressed record
+func (s *suppressed) add(sig string, rec Record, keys []string) {
+	if s.counts == nil {
+		s.counts = make(map[string]*suppression)
+	}
+	c := s.counts[sig]
+	if c == nil {
+		c = &suppression{rec: rec.Clone()}
+		if len(keys) > 0 {
+			c.rec = make(Record, len(keys))
+			for _, k := range keys {
+				if v, exists := rec[k]; exists {
+					c.rec[k] = v
+				}
+			}
+		}
+		s.counts[sig] = c
+	}
+	c.n++
+}
+
+// summaries returns a summary record per signature, in signature order, and resets the counters
+func (s *suppressed) summaries() []Record {
+	if len(s.counts) == 0 {
+		return nil
+	}
+	sigs := make([]string, 0, len(s.counts))
+	for sig := range s.counts {
+		sigs = append(sigs, sig)
+	}
+	sort.Strings(sigs)
+	summaries := make([]Record, len(sigs))
+	for i, sig := range sigs {
+		c := s.counts[sig]
+		summaries[i] = *c.rec.Int64(SuppressedKey, int64(c.n))
+	}
+	s.counts = nil
+	return summaries
+}
@@ -0,0 +1,118 @@
+package logfmt
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clock is a fake clock, for deterministic tests
+type clock struct{ t time.Time }
+
+func (c *clock) Now() time.Time          { return c.t }
+func (c *clock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+func newClock() *clock                   { return &clock{t: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)} }
+
+func ExampleSampling() {
+	c := newClock()
+	logger := New(os.Stdout)
+	logger.SetSampler(&Sampling{First: 2, Every: 3, Keys: []string{"msg"}, Period: time.Second, Now: c.Now})
+
+	for i := 1; i <= 6; i++ {
+		logger.Log(*S("msg", "retry").D("i", i))
+	}
+	c.Advance(time.Second)
+	logger.Log(*S("msg", "done"))
+
+	//Output:
+	// i=1 msg=retry
+	// i=2 msg=retry
+	// i=5 msg=retry
+	// msg=retry level=warn suppressed=3
+	// msg=done
+}
+
+func TestRateLimit(t *testing.T) {
+	c := newClock()
+	var buf bytes.Buffer
+	logger := New(&buf)
+	logger.SetSampler(&RateLimit{Rate: 2, Burst: 3, Key: "msg", Period: time.Minute, Now: c.Now})
+
+	count := func(msg string) int { return strings.Count(buf.String(), "msg="+msg+"\n") }
+	for i := 0; i < 10; i++ {
+		logger.Log(*S("msg", "a"))
+	}
+	logger.Log(*S("msg", "b")) // buckets are per key value
+	if count("a") != 3 || count("b") != 1 {
+		t.Fatalf("burst: got %q", buf.String())
+	}
+
+	c.Advance(time.Second) // two more tokens
+	for i := 0; i < 10; i++ {
+		logger.Log(*S("msg", "a"))
+	}
+	if count("a") != 5 {
+		t.Fatalf("rate: got %q", buf.String())
+	}
+
+	c.Advance(time.Minute)
+	logger.Log(*S("msg", "a"))
+	if !strings.HasSuffix(buf.String(), "msg=a level=warn suppressed=15\nmsg=a\n") {
+		t.Errorf("summary: got %q", buf.String())
+	}
+
+	// pending summaries are written on Close
+	for i := 0; i < 10; i++ {
+		logger.Log(*S("msg", "b"))
+	}
+	logger.Close()
+	if !strings.HasSuffix(buf.String(), "msg=b level=warn suppressed=7\n") {
+		t.Errorf("close: got %q", buf.String())
+	}
+}
+
+func TestSamplingSignature(t *testing.T) {
+	// without keys, only identical records share a signature
+	var buf bytes.Buffer
+	logger := New(&buf)
+	logger.SetSampler(&Sampling{First: 1, Now: newClock().Now})
+	for i := 0; i < 3; i++ {
+		logger.Log(*D("a", 1))
+		logger.Log(*D("a", 2))
+	}
+	logger.SetSampler(nil)
+	logger.Log(*D("a", 1))
+	if got, want := buf.String(), "a=1\na=2\na=1\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestSamplingFilter(t *testing.T) {
+	// filtered records do not count, whether they are logged as Record or Fields
+	var buf bytes.Buffer
+	logger := New(&buf)
+	logger.SetFilter(func(rec Record) bool { return !rec.IsFlag("debug") })
+	logger.SetSampler(&Sampling{First: 1, Keys: []string{"msg"}, Now: newClock().Now})
+	logger.Log(*S("msg", "a").K("debug"))
+	logger.LogFields(Fields{{Key: "msg", Val: literal("a")}, {Key: "debug"}})
+	logger.LogFields(Fields{{Key: "msg", Val: literal("a")}})
+	if got, want := buf.String(), "msg=a\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestNoSamplerAllocs(t *testing.T) {
+	logger := New(io.Discard)
+	val := "1"
+	allocs := testing.AllocsPerRun(100, func() {
+		rec := make(Record, 2) // it stays on the stack, unless the Logger retains it
+		rec["a"], rec["b"] = &val, &val
+		logger.Log(rec)
+	})
+	if allocs > 0 {
+		t.Errorf("got %v allocs per Log without Sampler, want 0", allocs)
+	}
+}
@@ -0,0 +1,145 @@
+package logfmt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// The attributes of a span
+const (
+	SpanKey    = "span"        // the span name
+	SpanIDKey  = "span.id"     // the span id, for nested spans
+	ParentKey  = "span.parent" // the id of the parent span
+	StartKey   = "start"       // the start time
+	ElapsedKey = "elapsed"     // the time elapsed since the start
+	OutcomeKey = "outcome"     // 'ok' or 'error'
+)
+
+// spanClock is the clock of the spans
+var spanClock = time.Now
+
+// SpanRecord is a Record that times an operation, see Span.
+//
+// Its builders insert attributes like the Record ones, and return the
+// SpanRecord, to end the chain with End.
+type SpanRecord struct {
+	Record
+	start time.Time // with its monotonic clock reading
+	once  sync.Once // sets 'id', on the first child
+	id    *string   // the span id, for the children
+}
+
+// Span starts a Record that times an operation: End logs it, with the elapsed
+// time and the outcome.
+//
+//    func query(table string) (err error) {
+//        defer logfmt.Span("db.query").S("table", table).End(&err)
+//        ...
+//    }
+//
+// It logs `span=db.query level=info start=2024-03-01T12:00:00Z table=users elapsed=1.2ms outcome=ok`.
+func Span(name string) *SpanRecord {
+	s := &SpanRecord{Record: make(Record), start: spanClock()}
+	s.Record.S(SpanKey, name).Time(StartKey, s.start)
+	return s
+}
+
+// Span starts a nested span: a Span with a new 'span.id' and the id of this
+// span in 'span.parent'. This span gets an id if it has none, children can be
+// started concurrently.
+//
+//    req := logfmt.Span("request")
+//    defer req.End(&err)
+//    ...
+//    defer req.Span("db.query").End(&err)
+func (s *SpanRecord) Span(name string) *SpanRecord {
+	s.once.Do(func() {
+		if id := s.Record[SpanIDKey]; id != nil {
+			s.id = id
+			return
+		}
+		s.Record.S(SpanIDKey, spanID())
+		s.id = s.Record[SpanIDKey]
+	})
+	child := Span(name).S(SpanIDKey, spanID())
+	child.Record[ParentKey] = s.id
+	return child
+}
+
+// spanID returns a new random span id
+func spanID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// End logs this span into the 'Default' Logger, see Logger.End.
+func (s *SpanRecord) End(err *error) { Default.End(s, err) }
+
+// End logs a span started by Span, with the time elapsed since its start, and
+// its outcome: 'ok' at info level, or 'error' at error level if 'err' points
+// to a non nil error, also written in the 'error' attribute. 'err' can be nil.
+func (l *Logger) End(s *SpanRecord, err *error) {
+	rec := s.Record
+	rec.Duration(ElapsedKey, spanClock().Sub(s.start))
+	if err == nil || *err == nil {
+		rec.S(OutcomeKey, "ok")
+		l.Info(rec)
+		return
+	}
+	rec.S(OutcomeKey, "error").Err("error", *err)
+	l.Error(rec)
+}
+
+// Q inserts a quoted string attribute, see Record.Q
+func (s *SpanRecord) Q(key, val string) *SpanRecord { s.Record.Q(key, val); return s }
+
+// S inserts an identifier attribute, see Record.S
+func (s *SpanRecord) S(key, val string) *SpanRecord { s.Record.S(key, val); return s }
+
+// D inserts an integer attribute, see Record.D
+func (s *SpanRecord) D(key string, val int) *SpanRecord { s.Record.D(key, val); return s }
+
+// T inserts a boolean attribute, see Record.T
+func (s *SpanRecord) T(key string, val bool) *SpanRecord { s.Record.T(key, val); return s }
+
+// G inserts a float attribute, see Record.G
+func (s *SpanRecord) G(key string, val float64) *SpanRecord { s.Record.G(key, val); return s }
+
+// K inserts a key only attribute, see Record.K
+func (s *SpanRecord) K(key string) *SpanRecord { s.Record.K(key); return s }
+
+// V inserts any value, see Record.V
+func (s *SpanRecord) V(key string, val interface{}) *SpanRecord { s.Record.V(key, val); return s }
+
+// Int64 inserts a 64-bit integer attribute, see Record.Int64
+func (s *SpanRecord) Int64(key string, val int64) *SpanRecord { s.Record.Int64(key, val); return s }
+
+// Uint64 inserts an unsigned 64-bit integer attribute, see Record.Uint64
+func (s *SpanRecord) Uint64(key string, val uint64) *SpanRecord { s.Record.Uint64(key, val); return s }
+
+// Time inserts a time attribute, see Record.Time
+func (s *SpanRecord) Time(key string, val time.Time) *SpanRecord { s.Record.Time(key, val); return s }
+
+// Duration inserts a duration attribute, see Record.Duration
+func (s *SpanRecord) Duration(key string, val time.Duration) *SpanRecord {
+	s.Record.Duration(key, val)
+	return s
+}
+
+// Err inserts an error attribute, see Record.Err
+func (s *SpanRecord) Err(key string, err error) *SpanRecord { s.Record.Err(key, err); return s }
+
+// Hex inserts a []byte attribute in hexadecimal, see Record.Hex
+func (s *SpanRecord) Hex(key string, val []byte) *SpanRecord { s.Record.Hex(key, val); return s }
+
+// Base64 inserts a []byte attribute in base64, see Record.Base64
+func (s *SpanRecord) Base64(key string, val []byte) *SpanRecord { s.Record.Base64(key, val); return s }
+
+// Struct inserts the fields of a struct, see Record.Struct
+func (s *SpanRecord) Struct(prefix string, v interface{}) *SpanRecord {
+	s.Record.Struct(prefix, v)
+	return s
+}
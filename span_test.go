@@ -0,0 +1,101 @@
+package logfmt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSpanClock makes the span clock advance by 'step' at each call, until the end of the test
+func fakeSpanClock(tb testing.TB, step time.Duration) {
+	t := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	saved := spanClock
+	spanClock = func() time.Time { t = t.Add(step); return t }
+	if tb != nil {
+		tb.Cleanup(func() { spanClock = saved })
+	}
+}
+
+func ExampleSpan() {
+	fakeSpanClock(nil, 1500*time.Microsecond)
+	defer func() { spanClock = time.Now }()
+	logger := New(os.Stdout)
+
+	query := func(table string) (err error) {
+		defer logger.End(Span("db.query").S("table", table), &err)
+		if table == "" {
+			return errors.New("no table")
+		}
+		return nil
+	}
+	query("users")
+	query("")
+
+	//Output:
+	// span=db.query level=info start=2024-03-01T12:00:00.0015Z table=users elapsed=1.5ms outcome=ok
+	// span=db.query error="no table" level=error start=2024-03-01T12:00:00.0045Z table="" elapsed=1.5ms outcome=error
+}
+
+func TestNestedSpan(t *testing.T) {
+	fakeSpanClock(t, time.Millisecond)
+	var buf bytes.Buffer
+	saved := Default
+	Default = New(&buf)
+	defer func() { Default = saved }()
+
+	str := func(s *SpanRecord, key string) string { v, _ := s.Str(key); return v }
+	req := Span("request")
+	child := req.Span("db.query")
+	id, parent := str(req, SpanIDKey), str(child, ParentKey)
+	if id == "" || id != parent {
+		t.Fatalf("got parent %q want %q", parent, id)
+	}
+	if str(child, SpanIDKey) == id {
+		t.Errorf("the child has its parent id %q", id)
+	}
+	if str(req.Span("cache"), ParentKey) != id {
+		t.Errorf("the parent id has changed")
+	}
+
+	var err error
+	child.End(&err)
+	req.End(nil)
+	want := "span=db.query level=info start=2024-03-01T12:00:00.002Z elapsed=2ms outcome=ok span.id=" + str(child, SpanIDKey) + " span.parent=" + id + "\n" +
+		"span=request level=info start=2024-03-01T12:00:00.001Z elapsed=4ms outcome=ok span.id=" + id + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSpanTimeFormat(t *testing.T) {
+	fakeSpanClock(t, 1500*time.Millisecond)
+	defer func(format string) { TimeFormat = format }(TimeFormat)
+	TimeFormat = "15:04:05" // the start time is not parsed back
+
+	var buf bytes.Buffer
+	New(&buf).End(Span("job"), nil)
+	if got, want := buf.String(), "span=job level=info start=12:00:01 elapsed=1.5s outcome=ok\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestConcurrentSpan(t *testing.T) {
+	req := Span("request")
+	children := make([]*SpanRecord, 4)
+	var wg sync.WaitGroup
+	for i := range children {
+		wg.Add(1)
+		go func(i int) { defer wg.Done(); children[i] = req.Span("child") }(i)
+	}
+	wg.Wait()
+
+	id, _ := req.Str(SpanIDKey)
+	for _, child := range children {
+		if parent, _ := child.Str(ParentKey); id == "" || parent != id {
+			t.Errorf("got parent %q want %q", parent, id)
+		}
+	}
+}
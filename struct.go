@@ -0,0 +1,184 @@
+package logfmt
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// FromStruct returns a Record of the exported fields of the struct 'v', see Record.Struct.
+func FromStruct(v interface{}) Record {
+	rec := Rec()
+	rec.Struct("", v)
+	return *rec
+}
+
+// Struct inserts the exported fields of the struct 'v', with keys prefixed by 'prefix.'.
+//
+// Nested structs and maps are flattened into dotted keys: `req.method=GET
+// req.path=/`. Pointers are followed, once: cycles are cut. Other values are
+// inserted like V does, and so are the types implementing Marshaler,
+// encoding.TextMarshaler, fmt.Stringer or error.
+//
+// Fields are configured by their 'logfmt' tag:
+//
+//    Method string `logfmt:"method,omitempty,quote"`
+//
+// The tag sets the field key (its name by default, "-" to skip it), options
+// are 'omitempty', to skip the zero value, and 'quote' to always quote the
+// value. Fields of embedded structs without a tag are inserted as if they
+// were in the outer struct.
+func (rec *Record) Struct(prefix string, v interface{}) *Record {
+	rec.walk(prefix, reflect.ValueOf(v), make(map[uintptr]bool))
+	return rec
+}
+
+// structField is the cached metadata of a struct field
+type structField struct {
+	index     int
+	name      string
+	omitEmpty bool
+	quote     bool
+	inline    bool // embedded struct: its fields are inserted in the outer struct
+}
+
+// structFields caches the []structField of struct types
+var structFields sync.Map
+
+// fieldsOf returns the fields of a struct type to insert
+func fieldsOf(t reflect.Type) []structField {
+	if fields, ok := structFields.Load(t); ok {
+		return fields.([]structField)
+	}
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("logfmt")
+		if !sf.IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		f := structField{index: i, name: name}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				f.omitEmpty = true
+			case "quote":
+				f.quote = true
+			}
+		}
+		if f.name == "" {
+			f.name = sf.Name
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			f.inline = sf.Anonymous && ft.Kind() == reflect.Struct && !isLeaf(sf.Type)
+		}
+		fields = append(fields, f)
+	}
+	actual, _ := structFields.LoadOrStore(t, fields)
+	return actual.([]structField)
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// isLeaf returns true if values of type 't' are inserted as a whole, by V
+func isLeaf(t reflect.Type) bool {
+	return t.Implements(marshalerType) || t.Implements(textMarshalerType) ||
+		t.Implements(stringerType) || t.Implements(errorType)
+}
+
+// walk inserts 'v' under 'key', flattening structs and maps. 'visited' are
+// the pointers being walked through.
+func (rec *Record) walk(key string, v reflect.Value, visited map[uintptr]bool) {
+	for {
+		switch v.Kind() {
+		case reflect.Invalid:
+			if key != "" {
+				rec.V(key, nil)
+			}
+			return
+		case reflect.Ptr, reflect.Interface, reflect.Map:
+			if v.IsNil() {
+				if key != "" {
+					rec.V(key, nil)
+				}
+				return
+			}
+		}
+		if key != "" && v.Kind() != reflect.Interface && isLeaf(v.Type()) {
+			rec.V(key, v.Interface())
+			return
+		}
+		switch v.Kind() {
+		case reflect.Interface:
+			v = v.Elem()
+			continue
+		case reflect.Ptr, reflect.Map:
+			p := v.Pointer()
+			if visited[p] {
+				return // a cycle
+			}
+			visited[p] = true
+			defer delete(visited, p)
+		}
+		if v.Kind() != reflect.Ptr {
+			break
+		}
+		v = v.Elem()
+	}
+	if key == "" && v.Kind() != reflect.Struct && v.Kind() != reflect.Map {
+		return // a value without a key
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, f := range fieldsOf(v.Type()) {
+			fv := v.Field(f.index)
+			if f.omitEmpty && fv.IsZero() || f.inline && fv.Kind() == reflect.Ptr && fv.IsNil() {
+				continue
+			}
+			if f.inline {
+				rec.walk(key, fv, visited)
+				continue
+			}
+			k := join(key, f.name)
+			rec.walk(k, fv, visited)
+			if val := (*rec)[k]; f.quote && val != nil && !strings.HasPrefix(*val, `"`) {
+				rec.Q(k, *val)
+			}
+		}
+	case reflect.Map:
+		for it := v.MapRange(); it.Next(); {
+			rec.walk(join(key, fmt.Sprint(it.Key().Interface())), it.Value(), visited)
+		}
+	case reflect.String:
+		rec.S(key, v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		rec.Int64(key, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		rec.Uint64(key, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		rec.G(key, v.Float())
+	case reflect.Bool:
+		rec.T(key, v.Bool())
+	default:
+		rec.V(key, v.Interface())
+	}
+}
+
+// join returns the dotted key 'prefix.name'
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,68 @@
+package logfmt
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+type request struct {
+	Method  string            `logfmt:"method"`
+	Path    string            `logfmt:"path,quote"`
+	Query   string            `logfmt:"query,omitempty"`
+	Header  map[string]string `logfmt:"header"`
+	Elapsed time.Duration     `logfmt:"elapsed"`
+	Token   string            `logfmt:"-"`
+}
+
+type session struct {
+	User
+	Req   *request `logfmt:"req"`
+	Valid bool     `logfmt:"valid"`
+}
+
+type User struct {
+	ID   int    `logfmt:"user.id"`
+	Name string `logfmt:"user.name,omitempty"`
+}
+
+func ExampleFromStruct() {
+	Default = New(os.Stdout)
+	req := &request{Method: "GET", Path: "/", Header: map[string]string{"accept": "*/*"}, Elapsed: 15 * time.Millisecond, Token: "secret"}
+	FromStruct(session{User: User{ID: 12}, Req: req, Valid: true}).Log()
+	//Output: valid=true user.id=12 req.path="/" req.method=GET req.elapsed=15ms req.header.accept=*/*
+}
+
+func ExampleRecord_Struct() {
+	Default = New(os.Stdout)
+	S("msg", "moved").Struct("to", struct{ X, Y int }{3, 4}).Log()
+	//Output: msg=moved to.X=3 to.Y=4
+}
+
+type node struct {
+	Name string `logfmt:"name"`
+	Next *node  `logfmt:"next"`
+}
+
+func TestStructCycle(t *testing.T) {
+	a, b := &node{Name: "a"}, &node{Name: "b"}
+	a.Next, b.Next = b, a
+	got := FromStruct(a).String()
+	if want := "name=a next.name=b"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	// the same pointer twice is not a cycle
+	c := &node{Name: "c"}
+	got = FromStruct(struct{ A, B *node }{c, c}).String()
+	if want := "A.name=c A.next=<nil> B.name=c B.next=<nil>"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func BenchmarkFromStruct(b *testing.B) {
+	req := &request{Method: "GET", Path: "/", Header: map[string]string{"accept": "*/*"}, Elapsed: 15 * time.Millisecond}
+	for i := 0; i < b.N; i++ {
+		FromStruct(session{User: User{ID: 12, Name: "eric"}, Req: req})
+	}
+}
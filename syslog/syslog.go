@@ -0,0 +1,265 @@
+// Package syslog sends logfmt records to a syslog collector, in RFC 5424 frames.
+//
+//    w := &syslog.Writer{Network: "tcp", Addr: "collector:514", AppName: "api"}
+//    logger := logfmt.New(w)
+//    defer logger.Close()
+//
+// Each line written by the Logger is a syslog message: its priority is
+// computed from the 'level' attribute, and the line is the message itself.
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etnz/logfmt"
+	"github.com/etnz/logfmt/logreader"
+)
+
+// Facilities, see RFC 5424 section 6.2.1
+const (
+	Kern   = 0
+	User   = 1
+	Daemon = 3
+	Local0 = 16
+	Local7 = 23
+)
+
+// Severities, see RFC 5424 section 6.2.1
+const (
+	SevError   = 3
+	SevWarning = 4
+	SevNotice  = 5
+	SevInfo    = 6
+	SevDebug   = 7
+)
+
+// severities of the logfmt Levels
+var severities = map[string]int{
+	logfmt.LevelDebug.String(): SevDebug,
+	logfmt.LevelInfo.String():  SevInfo,
+	logfmt.LevelWarn.String():  SevWarning,
+	logfmt.LevelError.String(): SevError,
+}
+
+// timeFormat is the RFC 5424 timestamp layout, at most microseconds
+const timeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// Writer is an io.WriteCloser sending each line to a syslog collector, in an
+// RFC 5424 frame.
+//
+// Network is "udp" or "unixgram" to send a datagram per message, or "tcp" or
+// "unix" to send messages on a stream, with octet counting framing (RFC 6587).
+// The connection is opened on the first Write, and opened again when sending
+// fails.
+//
+// It is safe for concurrent use. Its fields must be set before the first Write.
+type Writer struct {
+	Network, Addr string
+
+	Facility int              // User if zero
+	Hostname string           // os.Hostname if empty
+	AppName  string           // the program name if empty
+	ProcID   string           // the process id if empty
+	MsgID    string           // none if empty
+	SDID     string           // if set, the attributes are also sent as structured data, with this SD-ID, like 'logfmt@32473'
+	Now      func() time.Time // the clock, time.Now if nil
+
+	lock    sync.Mutex // guards the fields below
+	conn    net.Conn
+	partial []byte // the beginning of a line, not written yet
+	header  string // HOSTNAME APP-NAME PROCID MSGID
+}
+
+// Write sends each line of 'p' as a syslog message. An unterminated line is
+// sent when it is completed by the next Write.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	data := p
+	if len(w.partial) > 0 {
+		data = append(w.partial, p...)
+	}
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.send(data[:i]); err != nil {
+			w.partial = w.partial[:0]
+			return 0, err
+		}
+		data = data[i+1:]
+	}
+	w.partial = append(w.partial[:0], data...)
+	return len(p), nil
+}
+
+// send a line as a syslog message, reconnecting once if needed
+func (w *Writer) send(line []byte) error {
+	msg := w.frame(line)
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if w.conn, err = net.Dial(w.Network, w.Addr); err != nil {
+				w.conn = nil
+				continue
+			}
+		}
+		if _, err = w.conn.Write(msg); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return err
+}
+
+// stream returns true if messages are framed with octet counting
+func (w *Writer) stream() bool {
+	switch w.Network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return false
+	}
+	return true
+}
+
+// frame returns the syslog message of a line
+func (w *Writer) frame(line []byte) []byte {
+	fields, _ := logreader.ParseFields(string(line))
+	severity := SevInfo
+	if level, exists := fields.Get(logfmt.LevelKey); exists && level != nil {
+		if s, ok := severities[strings.Trim(*level, `"`)]; ok {
+			severity = s
+		}
+	}
+	facility := w.Facility
+	if facility == 0 {
+		facility = User
+	}
+	now := time.Now
+	if w.Now != nil {
+		now = w.Now
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "<%d>1 %s %s ", facility*8+severity, now().Format(timeFormat), w.headerFields())
+	w.structuredData(&msg, fields)
+	msg.WriteByte(' ')
+	msg.Write(line)
+	if !w.stream() {
+		return msg.Bytes()
+	}
+	return append([]byte(strconv.Itoa(msg.Len())+" "), msg.Bytes()...)
+}
+
+// headerFields returns the HOSTNAME APP-NAME PROCID MSGID fields
+func (w *Writer) headerFields() string {
+	if w.header == "" {
+		hostname := w.Hostname
+		if hostname == "" {
+			hostname, _ = os.Hostname()
+		}
+		app := w.AppName
+		if app == "" {
+			app = filepath.Base(os.Args[0])
+		}
+		procid := w.ProcID
+		if procid == "" {
+			procid = strconv.Itoa(os.Getpid())
+		}
+		w.header = strings.Join([]string{
+			headerField(hostname, 255),
+			headerField(app, 48),
+			headerField(procid, 128),
+			headerField(w.MsgID, 32),
+		}, " ")
+	}
+	return w.header
+}
+
+// headerField returns a valid header field: printable ASCII, at most 'max' bytes, "-" if empty
+func headerField(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// structuredData writes the attributes as an SD-ELEMENT, "-" if there is no SDID
+func (w *Writer) structuredData(msg *bytes.Buffer, fields logfmt.Fields) {
+	if w.SDID == "" {
+		msg.WriteByte('-')
+		return
+	}
+	msg.WriteByte('[')
+	msg.WriteString(w.SDID)
+	for _, p := range fields {
+		if !isSDName(p.Key) {
+			continue // it cannot be a PARAM-NAME
+		}
+		val := ""
+		if p.Val != nil {
+			val = *p.Val
+			if s, err := strconv.Unquote(val); err == nil {
+				val = s
+			}
+		}
+		msg.WriteByte(' ')
+		msg.WriteString(p.Key)
+		msg.WriteString(`="`)
+		msg.WriteString(sdEscaper.Replace(val))
+		msg.WriteByte('"')
+	}
+	msg.WriteByte(']')
+}
+
+// sdEscaper escapes PARAM-VALUE, see RFC 5424 section 6.3.3
+var sdEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// isSDName returns true if 's' is a valid SD-NAME
+func isSDName(s string) bool {
+	if s == "" || len(s) > 32 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			return false
+		}
+	}
+	return true
+}
+
+// Close sends the unterminated line, if any, and closes the connection.
+func (w *Writer) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	var err error
+	if len(w.partial) > 0 {
+		err = w.send(w.partial)
+		w.partial = w.partial[:0]
+	}
+	if w.conn != nil {
+		if cerr := w.conn.Close(); err == nil {
+			err = cerr
+		}
+		w.conn = nil
+	}
+	return err
+}
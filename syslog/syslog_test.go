@@ -0,0 +1,211 @@
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etnz/logfmt"
+)
+
+// at is the fixed time of the test messages
+var at = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func now() time.Time { return at }
+
+// readFrame reads an octet counted message
+func readFrame(r *bufio.Reader) (string, error) {
+	size, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(size, " "))
+	if err != nil {
+		return "", err
+	}
+	msg := make([]byte, n)
+	_, err = io.ReadFull(r, msg)
+	return string(msg), err
+}
+
+// listen starts a stream collector, sending the messages it receives to the returned channel
+func listen(t *testing.T, network, addr string) (net.Listener, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	messages := make(chan string, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					msg, err := readFrame(r)
+					if err != nil {
+						return
+					}
+					messages <- msg
+				}
+			}()
+		}
+	}()
+	return ln, messages
+}
+
+func receive(t *testing.T, messages <-chan string) string {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return ""
+	}
+}
+
+func TestUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	w := &Writer{Network: "udp", Addr: pc.LocalAddr().String(), Facility: Local0, Hostname: "host", AppName: "app", ProcID: "42", Now: now}
+	logger := logfmt.New(w)
+	logger.Warn(*logfmt.S("disk", "full"))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<132>1 2024-03-01T12:00:00.000000Z host app 42 - - disk=full level=warn`
+	if got := string(buf[:n]); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestTCP(t *testing.T) {
+	ln, messages := listen(t, "tcp", "127.0.0.1:0")
+	w := &Writer{Network: "tcp", Addr: ln.Addr().String(), Hostname: "host", AppName: "app", ProcID: "42", MsgID: "req", Now: now}
+	logger := logfmt.New(w)
+	defer logger.Close()
+
+	logger.Error(*logfmt.Q("msg", "a \"quoted\" message"))
+	logger.Log(*logfmt.K("started"))
+	if err := logger.Err(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<11>1 2024-03-01T12:00:00.000000Z host app 42 req - msg="a \"quoted\" message" level=error`,
+		`<14>1 2024-03-01T12:00:00.000000Z host app 42 req - started`,
+	} {
+		if got := receive(t, messages); got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+	}
+}
+
+func TestStructuredData(t *testing.T) {
+	ln, messages := listen(t, "tcp", "127.0.0.1:0")
+	w := &Writer{Network: "tcp", Addr: ln.Addr().String(), Hostname: "host", AppName: "app", ProcID: "42", SDID: "logfmt@32473", Now: now}
+	logger := logfmt.New(w)
+	defer logger.Close()
+
+	logger.Info(*logfmt.Q("path", `C:\a]b`).K("cached"))
+	want := `<14>1 2024-03-01T12:00:00.000000Z host app 42 - [logfmt@32473 path="C:\\a\]b" level="info" cached=""] path="C:\\a]b" level=info cached`
+	if got := receive(t, messages); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestUnix(t *testing.T) {
+	ln, messages := listen(t, "unix", filepath.Join(t.TempDir(), "log.sock"))
+	w := &Writer{Network: "unix", Addr: ln.Addr().String(), Hostname: "host", AppName: "app", ProcID: "42", Now: now}
+	logger := logfmt.New(w)
+	defer logger.Close()
+
+	logger.Debug(*logfmt.D("n", 1))
+	want := `<15>1 2024-03-01T12:00:00.000000Z host app 42 - - n=1 level=debug`
+	if got := receive(t, messages); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	w := &Writer{Network: "tcp", Addr: ln.Addr().String(), Hostname: "host", AppName: "app", ProcID: "42", Now: now}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("first=1\n")); err != nil {
+		t.Fatal(err)
+	}
+	first, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, err := readFrame(bufio.NewReader(first)); err != nil || !strings.HasSuffix(msg, "first=1") {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+	first.Close() // the collector restarts
+
+	// writes to the closed connection fail eventually, then the Writer reconnects
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	timeout := time.After(5 * time.Second)
+	for {
+		if _, err := w.Write([]byte("next=1\n")); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case conn := <-accepted:
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if msg, err := readFrame(bufio.NewReader(conn)); err != nil || !strings.HasSuffix(msg, "next=1") {
+				t.Fatalf("got %q, %v", msg, err)
+			}
+			return
+		case <-timeout:
+			t.Fatal("the Writer did not reconnect")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestPartialLine(t *testing.T) {
+	ln, messages := listen(t, "tcp", "127.0.0.1:0")
+	w := &Writer{Network: "tcp", Addr: ln.Addr().String(), Hostname: "host", AppName: "app", ProcID: "42", Now: now}
+	defer w.Close()
+
+	w.Write([]byte("a=1 b"))
+	w.Write([]byte("=2\nc=3\n"))
+	for _, want := range []string{"a=1 b=2", "c=3"} {
+		if got := receive(t, messages); !strings.HasSuffix(got, " - - "+want) {
+			t.Errorf("got %q want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package logfmt
+
+import "io"
+
+// Filter decides if a Logger writes a record. It gets the record merged with
+// the bound attributes, and the LevelKey attribute of leveled records.
+type Filter func(rec Record) bool
+
+// SetFilter sets the Filter of the records to write, nil to write them all.
+//
+// It is shared with the child Loggers, it is safe to call it at any time.
+func (l *Logger) SetFilter(f Filter) {
+	if f == nil {
+		l.filter.Store(nil)
+		return
+	}
+	l.filter.Store(&f)
+}
+
+// Tee returns a Logger that writes every record to all of 'loggers'.
+//
+// Records are merged with the Tee bound attributes, and passed on to each
+// Logger, with their LevelKey attribute. Each Logger applies its own rules:
+// Level, Filter, Sampler, Redactor, order, and writes to its own output.
+//
+//    errors := New(os.Stderr)
+//    errors.SetLevel(LevelError)
+//    logger := Tee(New(file), errors)
+//
+// Flush and Close apply to all of 'loggers'.
+func Tee(loggers ...*Logger) *Logger {
+	l := New(io.Discard)
+	l.tee = loggers
+	return l
+}
+
+// merge returns 'rec' merged with the bound attributes, and the LevelKey
+// attribute, if 'level' is not nil.
+func (l *Logger) merge(level *string, rec Record) Record {
+	merged := make(Record, len(l.bound)+len(rec)+1)
+	for _, p := range l.bound {
+		merged[p.Key] = p.Val
+	}
+	for k, v := range rec {
+		merged[k] = v
+	}
+	if level != nil {
+		merged[LevelKey] = level
+	}
+	return merged
+}
+
+// fanOut writes the record to all the Tee loggers
+func (l *Logger) fanOut(level *string, rec Record) {
+	merged := l.merge(level, rec)
+	if r := l.redactor.Load(); r != nil {
+		merged = r.Record(merged)
+	}
+	for _, t := range l.tee {
+		if level != nil {
+			if lv, err := ParseLevel(*level); err == nil && !t.Enabled(lv) {
+				continue
+			}
+		}
+		t.write(nil, merged)
+	}
+}
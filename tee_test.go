@@ -0,0 +1,31 @@
+package logfmt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+func ExampleTee() {
+	var file bytes.Buffer
+	errs := New(os.Stdout)
+	errs.SetLevel(LevelError)
+	logger := Tee(New(&file), errs)
+
+	logger.Info(*S("msg", "started"))
+	logger.Error(*S("msg", "failed"))
+	fmt.Print(file.String())
+
+	//Output:
+	// msg=failed level=error
+	// msg=started level=info
+	// msg=failed level=error
+}
+
+func ExampleLogger_SetFilter() {
+	logger := New(os.Stdout)
+	logger.SetFilter(func(rec Record) bool { return !rec.IsFlag("debug") })
+	logger.With(*K("debug")).Log(*S("msg", "dropped"))
+	logger.Log(*S("msg", "kept"))
+	//Output: msg=kept
+}
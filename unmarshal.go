@@ -0,0 +1,233 @@
+package logfmt
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnmarshalError describes an attribute that cannot be stored into a struct field.
+type UnmarshalError struct {
+	Key   string  // the attribute key
+	Value *string // the attribute value, nil for a key only attribute
+	Field string  // the struct field, like 'Request.Elapsed'
+	Type  string  // the field type
+	Err   error
+}
+
+func (e *UnmarshalError) Error() string {
+	attr := e.Key
+	if e.Value != nil {
+		attr += "=" + *e.Value
+	}
+	return fmt.Sprintf("logfmt: cannot unmarshal %s into %s (%s): %v", attr, e.Field, e.Type, e.Err)
+}
+
+// Unwrap returns the actual error.
+func (e *UnmarshalError) Unwrap() error { return e.Err }
+
+// ErrKeyOnly is returned when a key only attribute is stored into a field that is not a bool.
+var ErrKeyOnly = errors.New("key only attribute")
+
+// Unmarshal stores the attributes of 'rec' into the struct pointed to by 'v'.
+//
+// Fields are matched the way Record.Struct writes them: by their 'logfmt' tag,
+// nested structs and maps by dotted keys. Fields without an attribute are
+// left unchanged.
+//
+// Quoted values are unquoted. They are converted into strings, integers,
+// floats, bools, time.Duration, time.Time (in TimeFormat, or RFC 3339), or
+// by encoding.TextUnmarshaler. Key only attributes (see Record.K) are stored
+// as true in bool fields.
+//
+// Every field that cannot be set is reported as an *UnmarshalError, the other
+// fields are set.
+func Unmarshal(rec Record, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("logfmt: Unmarshal needs a non-nil pointer to a struct, not %T", v)
+	}
+	var errs []error
+	rec.unmarshalStruct("", rv.Elem(), &errs)
+	return errors.Join(errs...)
+}
+
+// unmarshalStruct sets the fields of the struct 'v' from the attributes prefixed by 'prefix.'
+func (rec Record) unmarshalStruct(prefix string, v reflect.Value, errs *[]error) {
+	t := v.Type()
+	for _, f := range fieldsOf(t) {
+		fv := v.Field(f.index)
+		if f.inline {
+			if rec.hasPrefix(prefix) {
+				rec.unmarshalStruct(prefix, alloc(fv), errs)
+			}
+			continue
+		}
+		key := join(prefix, f.name)
+		if val, exists := rec[key]; exists {
+			if err := setValue(fv, val); err != nil {
+				*errs = append(*errs, &UnmarshalError{
+					Key:   key,
+					Value: val,
+					Field: strings.TrimPrefix(t.Name()+"."+t.Field(f.index).Name, "."),
+					Type:  fv.Type().String(),
+					Err:   err,
+				})
+			}
+			continue
+		}
+		if isText(fv.Type()) || !rec.hasPrefix(key) {
+			continue
+		}
+		switch ft := deref(fv.Type()); {
+		case ft.Kind() == reflect.Struct:
+			rec.unmarshalStruct(key, alloc(fv), errs)
+		case ft.Kind() == reflect.Map && ft.Key().Kind() == reflect.String:
+			rec.unmarshalMap(key, alloc(fv), errs)
+		}
+	}
+}
+
+// unmarshalMap sets the entries of the map 'v' from the attributes prefixed by 'prefix.'
+func (rec Record) unmarshalMap(prefix string, v reflect.Value, errs *[]error) {
+	t := v.Type()
+	for key, val := range rec {
+		if !strings.HasPrefix(key, prefix+".") {
+			continue
+		}
+		e := reflect.New(t.Elem()).Elem()
+		if err := setValue(e, val); err != nil {
+			*errs = append(*errs, &UnmarshalError{Key: key, Value: val, Field: "map entry", Type: t.Elem().String(), Err: err})
+			continue
+		}
+		v.SetMapIndex(reflect.ValueOf(key[len(prefix)+1:]).Convert(t.Key()), e)
+	}
+}
+
+// hasPrefix returns true if a key starts with 'prefix.', any key for an empty prefix.
+func (rec Record) hasPrefix(prefix string) bool {
+	if prefix == "" {
+		return len(rec) > 0
+	}
+	for key := range rec {
+		if strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// deref returns the type pointed to by 't', if it is a pointer
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isText returns true if values of type 't' are read from a single attribute
+func isText(t reflect.Type) bool {
+	t = deref(t)
+	return t == timeType || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// alloc returns the value 'v' points to, allocating nil pointers and maps
+func alloc(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Map && v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	return v
+}
+
+// unquote returns the content of a quoted value, other values as is
+func unquote(val string) string {
+	if strings.HasPrefix(val, `"`) {
+		if s, err := strconv.Unquote(val); err == nil {
+			return s
+		}
+	}
+	return val
+}
+
+// setValue converts an attribute value and stores it into 'v'
+func setValue(v reflect.Value, val *string) error {
+	v = alloc(v)
+	if val == nil {
+		if v.Kind() != reflect.Bool {
+			return ErrKeyOnly
+		}
+		v.SetBool(true)
+		return nil
+	}
+	s := unquote(*val)
+
+	switch {
+	case v.Type() == timeType:
+		t, err := parseTime(s)
+		if err == nil {
+			v.Set(reflect.ValueOf(t))
+		}
+		return err
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err == nil {
+			v.SetInt(int64(d))
+		}
+		return err
+	case v.Addr().Type().Implements(textUnmarshalerType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(x)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+	case reflect.Bool:
+		x, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(x)
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		v.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
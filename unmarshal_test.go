@@ -0,0 +1,102 @@
+package logfmt
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func ExampleUnmarshal() {
+	var req struct {
+		Method  string        `logfmt:"method"`
+		Path    string        `logfmt:"path"`
+		Status  int           `logfmt:"status"`
+		Elapsed time.Duration `logfmt:"elapsed"`
+		Debug   bool          `logfmt:"debug"`
+	}
+	rec := *S("method", "GET").Q("path", "/index.html").D("status", 200).S("elapsed", "15ms").K("debug")
+	if err := Unmarshal(rec, &req); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Printf("%+v\n", req)
+	//Output: {Method:GET Path:/index.html Status:200 Elapsed:15ms Debug:true}
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	type round struct {
+		User
+		Req   *request          `logfmt:"req"`
+		At    time.Time         `logfmt:"at"`
+		Load  float64           `logfmt:"load"`
+		Size  uint16            `logfmt:"size"`
+		Color color             `logfmt:"color"`
+		Tags  map[string]string `logfmt:"tags"`
+	}
+	want := round{
+		User:  User{ID: 12, Name: "eric"},
+		Req:   &request{Method: "GET", Path: "/a b", Header: map[string]string{"accept": "*/*"}, Elapsed: 15 * time.Millisecond},
+		At:    time.Date(2024, 3, 1, 12, 30, 0, 500, time.UTC),
+		Load:  0.75,
+		Size:  512,
+		Color: 1,
+		Tags:  map[string]string{"env": "prod", "zone": "eu west"},
+	}
+	var got round
+	if err := Unmarshal(FromStruct(want), &got); err != nil {
+		t.Fatalf("Unmarshal() error %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+}
+
+// UnmarshalText completes color as an encoding.TextUnmarshaler
+func (c *color) UnmarshalText(text []byte) error {
+	for i, name := range []string{"red", "green"} {
+		if name == string(text) {
+			*c = color(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown color %q", text)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var v struct {
+		Status int    `logfmt:"status"`
+		Small  int8   `logfmt:"small"`
+		Name   string `logfmt:"name"`
+		Valid  bool   `logfmt:"valid"`
+	}
+	rec := *S("status", "ok").D("small", 300).K("name").T("valid", true)
+	err := Unmarshal(rec, &v)
+
+	var errs []*UnmarshalError
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var uerr *UnmarshalError
+		if !errors.As(e, &uerr) {
+			t.Fatalf("unexpected error %v", e)
+		}
+		errs = append(errs, uerr)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("got %d errors want 3: %v", len(errs), err)
+	}
+	if !v.Valid {
+		t.Errorf("valid fields must be set")
+	}
+	if !errors.Is(err, strconv.ErrSyntax) || !errors.Is(err, strconv.ErrRange) || !errors.Is(err, ErrKeyOnly) {
+		t.Errorf("unexpected errors %v", err)
+	}
+	want := `logfmt: cannot unmarshal status=ok into Status (int): strconv.ParseInt: parsing "ok": invalid syntax`
+	if got := errs[0].Error(); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	if err := Unmarshal(rec, v); err == nil {
+		t.Errorf("Unmarshal into a struct value must fail")
+	}
+}